@@ -0,0 +1,92 @@
+package batchwriter
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// NewConditionalPutWriter creates a new ConditionalPutWriter which only inserts items
+// that don't already exist in the table. keyNames are the names of the table's key
+// attributes. Since BatchWriteItem doesn't support conditions, each item is written
+// using PutItem, with up to concurrency requests executed in parallel.
+func NewConditionalPutWriter(region, tableName string, keyNames []string, concurrency int) (w *ConditionalPutWriter, err error) {
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return
+	}
+	w = newConditionalPutWriter(dynamodb.New(sess), tableName, keyNames, concurrency)
+	return
+}
+
+func newConditionalPutWriter(client dynamodbiface.DynamoDBAPI, tableName string, keyNames []string, concurrency int) *ConditionalPutWriter {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	conditions := make([]string, len(keyNames))
+	names := make(map[string]*string, len(keyNames))
+	for i, k := range keyNames {
+		placeholder := fmt.Sprintf("#k%d", i)
+		conditions[i] = fmt.Sprintf("attribute_not_exists(%s)", placeholder)
+		names[placeholder] = aws.String(k)
+	}
+	return &ConditionalPutWriter{
+		client:      client,
+		tableName:   tableName,
+		concurrency: concurrency,
+		condition:   strings.Join(conditions, " AND "),
+		names:       names,
+	}
+}
+
+// ConditionalPutWriter writes to DynamoDB tables using PutItem, skipping items which
+// already exist.
+type ConditionalPutWriter struct {
+	// inserted and skipped are first to guarantee 64-bit alignment for atomic operations.
+	inserted    int64
+	skipped     int64
+	client      dynamodbiface.DynamoDBAPI
+	tableName   string
+	concurrency int
+	condition   string
+	names       map[string]*string
+}
+
+// Write the records to DynamoDB. Records which already exist in the table are skipped.
+func (w *ConditionalPutWriter) Write(records []map[string]*dynamodb.AttributeValue) (err error) {
+	return parallel(records, w.concurrency, w.put)
+}
+
+func (w *ConditionalPutWriter) put(item map[string]*dynamodb.AttributeValue) (err error) {
+	_, err = w.client.PutItem(&dynamodb.PutItemInput{
+		TableName:                aws.String(w.tableName),
+		Item:                     item,
+		ConditionExpression:      aws.String(w.condition),
+		ExpressionAttributeNames: w.names,
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		atomic.AddInt64(&w.skipped, 1)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("batchwriter: conditional put failed: %w", err)
+	}
+	atomic.AddInt64(&w.inserted, 1)
+	return
+}
+
+// Inserted returns the number of items that have been written to the table.
+func (w *ConditionalPutWriter) Inserted() int64 {
+	return atomic.LoadInt64(&w.inserted)
+}
+
+// Skipped returns the number of items that were not written because they already existed.
+func (w *ConditionalPutWriter) Skipped() int64 {
+	return atomic.LoadInt64(&w.skipped)
+}
@@ -0,0 +1,84 @@
+package batchwriter
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+type mockPutter struct {
+	dynamodbiface.DynamoDBAPI
+	m        sync.Mutex
+	existing map[string]bool
+	inputs   []*dynamodb.PutItemInput
+	err      error
+}
+
+func (mp *mockPutter) PutItem(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	mp.m.Lock()
+	defer mp.m.Unlock()
+	mp.inputs = append(mp.inputs, input)
+	if mp.err != nil {
+		return nil, mp.err
+	}
+	id := *input.Item["id"].S
+	if mp.existing[id] {
+		return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "exists", nil)
+	}
+	mp.existing[id] = true
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func TestConditionalPutWriter(t *testing.T) {
+	client := &mockPutter{
+		existing: map[string]bool{"b": true},
+	}
+	w := newConditionalPutWriter(client, "table", []string{"id", "sk"}, 2)
+	records := []map[string]*dynamodb.AttributeValue{
+		{"id": {S: aws.String("a")}, "sk": {S: aws.String("1")}},
+		{"id": {S: aws.String("b")}, "sk": {S: aws.String("1")}},
+		{"id": {S: aws.String("c")}, "sk": {S: aws.String("1")}},
+	}
+	if err := w.Write(records); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.Inserted() != 2 {
+		t.Errorf("expected 2 inserted, got %d", w.Inserted())
+	}
+	if w.Skipped() != 1 {
+		t.Errorf("expected 1 skipped, got %d", w.Skipped())
+	}
+	if len(client.inputs) != 3 {
+		t.Fatalf("expected 3 puts, got %d", len(client.inputs))
+	}
+	expectedCondition := "attribute_not_exists(#k0) AND attribute_not_exists(#k1)"
+	if actual := *client.inputs[0].ConditionExpression; actual != expectedCondition {
+		t.Errorf("expected condition %q, got %q", expectedCondition, actual)
+	}
+	if actual := *client.inputs[0].ExpressionAttributeNames["#k1"]; actual != "sk" {
+		t.Errorf("expected #k1 to be sk, got %q", actual)
+	}
+}
+
+func TestConditionalPutWriterStopsAfterError(t *testing.T) {
+	client := &mockPutter{
+		existing: map[string]bool{},
+		err:      awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "throttled", nil),
+	}
+	w := newConditionalPutWriter(client, "table", []string{"id"}, 1)
+	records := []map[string]*dynamodb.AttributeValue{
+		{"id": {S: aws.String("a")}},
+		{"id": {S: aws.String("b")}},
+		{"id": {S: aws.String("c")}},
+	}
+	if err := w.Write(records); err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(client.inputs) != 1 {
+		t.Errorf("expected writes to stop after the first error, got %d puts", len(client.inputs))
+	}
+}
@@ -0,0 +1,44 @@
+package batchwriter
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// NewDeleteWriter creates a new DeleteWriter which deletes items from a DynamoDB table
+// in batches. keyNames are the names of the table's key attributes.
+func NewDeleteWriter(region, tableName string, keyNames []string) (dw DeleteWriter, err error) {
+	bw, err := New(region, tableName)
+	if err != nil {
+		return
+	}
+	dw = DeleteWriter{
+		BatchWriter: bw,
+		keyNames:    keyNames,
+	}
+	return
+}
+
+// DeleteWriter deletes items from DynamoDB tables using BatchWriteItem.
+type DeleteWriter struct {
+	BatchWriter
+	keyNames []string
+}
+
+// Write deletes the items identified by the records. Attributes other than the key
+// attributes are ignored.
+func (dw DeleteWriter) Write(records []map[string]*dynamodb.AttributeValue) (err error) {
+	keys := make([]map[string]*dynamodb.AttributeValue, len(records))
+	for i, r := range records {
+		keys[i] = make(map[string]*dynamodb.AttributeValue, len(dw.keyNames))
+		for _, k := range dw.keyNames {
+			v, ok := r[k]
+			if !ok {
+				return fmt.Errorf("batchwriter: delete failed: record is missing key attribute %q", k)
+			}
+			keys[i][k] = v
+		}
+	}
+	return dw.Delete(keys)
+}
@@ -0,0 +1,59 @@
+package batchwriter
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/google/go-cmp/cmp"
+)
+
+type mockBatchWriter struct {
+	dynamodbiface.DynamoDBAPI
+	inputs []*dynamodb.BatchWriteItemInput
+}
+
+func (m *mockBatchWriter) BatchWriteItem(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+	m.inputs = append(m.inputs, input)
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func TestDeleteWriter(t *testing.T) {
+	client := &mockBatchWriter{}
+	dw := DeleteWriter{
+		BatchWriter: BatchWriter{Backoff: NewBackoff(1), client: client, tableName: "table"},
+		keyNames:    []string{"id"},
+	}
+	err := dw.Write([]map[string]*dynamodb.AttributeValue{
+		{"id": {S: aws.String("a")}, "name": {S: aws.String("Alice")}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []*dynamodb.BatchWriteItemInput{
+		{
+			RequestItems: map[string][]*dynamodb.WriteRequest{
+				"table": {
+					{DeleteRequest: &dynamodb.DeleteRequest{Key: map[string]*dynamodb.AttributeValue{"id": {S: aws.String("a")}}}},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(expected, client.inputs); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestDeleteWriterMissingKey(t *testing.T) {
+	dw := DeleteWriter{
+		BatchWriter: BatchWriter{Backoff: NewBackoff(1), client: &mockBatchWriter{}, tableName: "table"},
+		keyNames:    []string{"id", "sk"},
+	}
+	err := dw.Write([]map[string]*dynamodb.AttributeValue{
+		{"id": {S: aws.String("a")}},
+	})
+	if err == nil {
+		t.Error("expected an error for the missing sort key")
+	}
+}
@@ -0,0 +1,42 @@
+package batchwriter
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// parallel executes f for each record, with up to concurrency executions at once. After the first
+// error, no further executions are started, and the error is returned once the executions that are
+// in progress have completed.
+func parallel(records []map[string]*dynamodb.AttributeValue, concurrency int, f func(record map[string]*dynamodb.AttributeValue) error) (err error) {
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var m sync.Mutex
+	failed := func() bool {
+		m.Lock()
+		defer m.Unlock()
+		return err != nil
+	}
+	for _, r := range records {
+		sem <- struct{}{}
+		if failed() {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func(record map[string]*dynamodb.AttributeValue) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if fErr := f(record); fErr != nil {
+				m.Lock()
+				defer m.Unlock()
+				if err == nil {
+					err = fErr
+				}
+			}
+		}(r)
+	}
+	wg.Wait()
+	return
+}
@@ -0,0 +1,172 @@
+package batchwriter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// ErrRollbackDisabled is returned by Rollback if the writer wasn't created with rollback enabled.
+var ErrRollbackDisabled = errors.New("batchwriter: rollback is not enabled")
+
+// NewTransactionWriter creates a new TransactionWriter which writes each batch of records to
+// a DynamoDB table using TransactWriteItems, so that either all of the records in the batch
+// are written, or none of them are. keyNames are the names of the table's key attributes.
+// token is used to derive the client request token of each transaction, so that retrying an
+// import with the same token within 10 minutes doesn't apply the same batch twice. If rollback
+// is true, the items are read before each transaction, so that Rollback can restore them.
+func NewTransactionWriter(region, tableName string, keyNames []string, token string, rollback bool) (tw *TransactionWriter, err error) {
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return
+	}
+	tw = newTransactionWriter(dynamodb.New(sess), tableName, keyNames, token, rollback)
+	return
+}
+
+func newTransactionWriter(client dynamodbiface.DynamoDBAPI, tableName string, keyNames []string, token string, rollback bool) *TransactionWriter {
+	return &TransactionWriter{
+		client:    client,
+		tableName: tableName,
+		keyNames:  keyNames,
+		token:     token,
+		rollback:  rollback,
+	}
+}
+
+// TransactionWriter writes to DynamoDB tables using TransactWriteItems. It keeps track of the
+// committed transactions, so it must not be used concurrently.
+type TransactionWriter struct {
+	client    dynamodbiface.DynamoDBAPI
+	tableName string
+	keyNames  []string
+	token     string
+	rollback  bool
+	// attempt is incremented by each rollback, so that the records are written again with new
+	// client request tokens.
+	attempt   int
+	committed []transaction
+}
+
+// transaction is a committed transaction. previous contains the item that existed before the
+// transaction for each key, or nil if there wasn't one.
+type transaction struct {
+	keys     []map[string]*dynamodb.AttributeValue
+	previous []map[string]*dynamodb.AttributeValue
+	items    int
+}
+
+// Write the records in a single transaction. DynamoDB limits the number of items in a
+// transaction, so records should be written in chunks of 25.
+func (tw *TransactionWriter) Write(records []map[string]*dynamodb.AttributeValue) (err error) {
+	chunk := len(tw.committed)
+	t := transaction{items: len(records)}
+	if tw.rollback {
+		if t.keys, t.previous, err = tw.get(records); err != nil {
+			return fmt.Errorf("batchwriter: transaction %d failed to read the existing items: %w", chunk, err)
+		}
+	}
+	transactItems := make([]*dynamodb.TransactWriteItem, len(records))
+	for i := 0; i < len(records); i++ {
+		put := &dynamodb.Put{
+			TableName: aws.String(tw.tableName),
+			Item:      records[i],
+		}
+		if tw.rollback && t.previous[i] == nil {
+			// The item didn't exist when it was read, so if another writer creates it before the
+			// transaction, the transaction fails rather than the rollback deleting their item.
+			put.ConditionExpression = aws.String("attribute_not_exists(#k)")
+			put.ExpressionAttributeNames = map[string]*string{"#k": aws.String(tw.keyNames[0])}
+		}
+		transactItems[i] = &dynamodb.TransactWriteItem{Put: put}
+	}
+	_, err = tw.client.TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+		ClientRequestToken: aws.String(tw.clientRequestToken(chunk)),
+		TransactItems:      transactItems,
+	})
+	if err != nil {
+		return fmt.Errorf("batchwriter: transaction %d failed: %w", chunk, err)
+	}
+	tw.committed = append(tw.committed, t)
+	return
+}
+
+// get reads the items with the keys of the records in a single transaction, so that the items are
+// consistent with each other.
+func (tw *TransactionWriter) get(records []map[string]*dynamodb.AttributeValue) (keys, previous []map[string]*dynamodb.AttributeValue, err error) {
+	keys = make([]map[string]*dynamodb.AttributeValue, len(records))
+	gets := make([]*dynamodb.TransactGetItem, len(records))
+	for i, r := range records {
+		keys[i] = make(map[string]*dynamodb.AttributeValue, len(tw.keyNames))
+		for _, k := range tw.keyNames {
+			keys[i][k] = r[k]
+		}
+		gets[i] = &dynamodb.TransactGetItem{
+			Get: &dynamodb.Get{
+				TableName: aws.String(tw.tableName),
+				Key:       keys[i],
+			},
+		}
+	}
+	tgo, err := tw.client.TransactGetItems(&dynamodb.TransactGetItemsInput{TransactItems: gets})
+	if err != nil {
+		return
+	}
+	previous = make([]map[string]*dynamodb.AttributeValue, len(records))
+	for i := range records {
+		if i < len(tgo.Responses) && len(tgo.Responses[i].Item) > 0 {
+			previous[i] = tgo.Responses[i].Item
+		}
+	}
+	return
+}
+
+// clientRequestToken derives a token of up to 36 characters for the chunk.
+func (tw *TransactionWriter) clientRequestToken(chunk int) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s/%d/%d", tw.token, tw.attempt, chunk)))
+	return hex.EncodeToString(hash[:16])
+}
+
+// Committed returns the number of transactions and items that have been committed.
+func (tw *TransactionWriter) Committed() (chunks, items int) {
+	for _, t := range tw.committed {
+		items += t.items
+	}
+	return len(tw.committed), items
+}
+
+// Rollback undoes the committed transactions, latest first. Items that existed before a
+// transaction are restored to their previous state, and items that were created are deleted.
+func (tw *TransactionWriter) Rollback() (err error) {
+	if !tw.rollback {
+		return ErrRollbackDisabled
+	}
+	bw := BatchWriter{
+		Backoff:   NewBackoff(7),
+		client:    tw.client,
+		tableName: tw.tableName,
+	}
+	for len(tw.committed) > 0 {
+		t := tw.committed[len(tw.committed)-1]
+		writeRequests := make([]*dynamodb.WriteRequest, len(t.previous))
+		for i, item := range t.previous {
+			if item == nil {
+				writeRequests[i] = &dynamodb.WriteRequest{DeleteRequest: &dynamodb.DeleteRequest{Key: t.keys[i]}}
+				continue
+			}
+			writeRequests[i] = &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: item}}
+		}
+		if err = bw.write(map[string][]*dynamodb.WriteRequest{tw.tableName: writeRequests}, 0); err != nil {
+			return fmt.Errorf("batchwriter: rollback failed with %d transactions remaining: %w", len(tw.committed), err)
+		}
+		tw.committed = tw.committed[:len(tw.committed)-1]
+	}
+	tw.attempt++
+	return
+}
@@ -0,0 +1,134 @@
+package batchwriter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/google/go-cmp/cmp"
+)
+
+type mockTransactor struct {
+	mockBatchWriter
+	failAfter int
+	existing  map[string]map[string]*dynamodb.AttributeValue
+	tokens    []string
+	puts      []*dynamodb.Put
+}
+
+func (m *mockTransactor) TransactGetItems(input *dynamodb.TransactGetItemsInput) (*dynamodb.TransactGetItemsOutput, error) {
+	output := &dynamodb.TransactGetItemsOutput{}
+	for _, get := range input.TransactItems {
+		output.Responses = append(output.Responses, &dynamodb.ItemResponse{Item: m.existing[*get.Get.Key["id"].S]})
+	}
+	return output, nil
+}
+
+func (m *mockTransactor) TransactWriteItems(input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+	if len(m.tokens) >= m.failAfter {
+		return nil, errors.New("transaction cancelled")
+	}
+	m.tokens = append(m.tokens, *input.ClientRequestToken)
+	for _, item := range input.TransactItems {
+		m.puts = append(m.puts, item.Put)
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func TestTransactionWriter(t *testing.T) {
+	alice := map[string]*dynamodb.AttributeValue{"id": {S: aws.String("a")}, "name": {S: aws.String("Alice Smith")}}
+	client := &mockTransactor{
+		failAfter: 2,
+		existing:  map[string]map[string]*dynamodb.AttributeValue{"a": alice},
+	}
+	tw := newTransactionWriter(client, "table", []string{"id"}, "token", true)
+	chunks := [][]map[string]*dynamodb.AttributeValue{
+		{{"id": {S: aws.String("a")}, "name": {S: aws.String("Alice")}}},
+		{{"id": {S: aws.String("b")}, "name": {S: aws.String("Bob")}}},
+		{{"id": {S: aws.String("c")}, "name": {S: aws.String("Charlie")}}},
+	}
+	var err error
+	for _, chunk := range chunks {
+		if err = tw.Write(chunk); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		t.Fatal("expected the third transaction to fail")
+	}
+	if chunks, items := tw.Committed(); chunks != 2 || items != 2 {
+		t.Errorf("expected 2 chunks and 2 items to be committed, got %d and %d", chunks, items)
+	}
+	if client.tokens[0] == client.tokens[1] {
+		t.Error("expected each transaction to have a different client request token")
+	}
+	if len(client.tokens[0]) > 36 {
+		t.Errorf("client request tokens must be 36 characters or less, got %d", len(client.tokens[0]))
+	}
+	if client.puts[0].ConditionExpression != nil {
+		t.Errorf("expected no condition on the existing item, got %q", *client.puts[0].ConditionExpression)
+	}
+	if client.puts[1].ConditionExpression == nil || *client.puts[1].ConditionExpression != "attribute_not_exists(#k)" {
+		t.Errorf("expected the new item to be written if it doesn't exist, got %v", client.puts[1].ConditionExpression)
+	}
+	if err = tw.Rollback(); err != nil {
+		t.Fatalf("unexpected rollback error: %v", err)
+	}
+	// The latest transaction is rolled back first, the new item is deleted, and the existing item is
+	// restored.
+	expected := []*dynamodb.BatchWriteItemInput{
+		{
+			RequestItems: map[string][]*dynamodb.WriteRequest{
+				"table": {
+					{DeleteRequest: &dynamodb.DeleteRequest{Key: map[string]*dynamodb.AttributeValue{"id": {S: aws.String("b")}}}},
+				},
+			},
+		},
+		{
+			RequestItems: map[string][]*dynamodb.WriteRequest{
+				"table": {
+					{PutRequest: &dynamodb.PutRequest{Item: alice}},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(expected, client.inputs); diff != "" {
+		t.Error(diff)
+	}
+	if chunks, items := tw.Committed(); chunks != 0 || items != 0 {
+		t.Errorf("expected nothing to be committed after the rollback, got %d chunks and %d items", chunks, items)
+	}
+
+	// Writing again after the rollback must not reuse the client request tokens, or DynamoDB would
+	// treat the transactions as already applied.
+	client.failAfter = 3
+	if err = tw.Write(chunks[0]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.tokens[2] == client.tokens[0] {
+		t.Error("expected a new client request token after the rollback")
+	}
+}
+
+func TestTransactionWriterRollbackDisabled(t *testing.T) {
+	client := &mockTransactor{failAfter: 1}
+	tw := newTransactionWriter(client, "table", []string{"id"}, "token", false)
+	if err := tw.Write([]map[string]*dynamodb.AttributeValue{{"id": {S: aws.String("a")}}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.puts[0].ConditionExpression != nil {
+		t.Error("expected no condition without rollback")
+	}
+	if err := tw.Rollback(); err != ErrRollbackDisabled {
+		t.Errorf("expected ErrRollbackDisabled, got %v", err)
+	}
+}
+
+func TestTransactionWriterTokensAreDeterministic(t *testing.T) {
+	a := newTransactionWriter(nil, "table", []string{"id"}, "token", false)
+	b := newTransactionWriter(nil, "table", []string{"id"}, "token", false)
+	if a.clientRequestToken(1) != b.clientRequestToken(1) {
+		t.Error("expected the same token and chunk to result in the same client request token")
+	}
+}
@@ -0,0 +1,138 @@
+package batchwriter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// UpdateOptions configure how records are merged into existing items.
+type UpdateOptions struct {
+	// AddFields are numeric attributes that are added to the existing value, e.g. counters,
+	// instead of replacing it.
+	AddFields []string
+	// RemoveEmpty removes attributes from the item when the record contains a NULL value
+	// for the attribute. When false, NULL values are ignored.
+	RemoveEmpty bool
+}
+
+// NewUpdateWriter creates a new UpdateWriter which uses UpdateItem to set the non-key
+// attributes of each record, leaving other attributes of existing items in place.
+// keyNames are the names of the table's key attributes. Up to concurrency UpdateItem
+// requests are executed in parallel.
+func NewUpdateWriter(region, tableName string, keyNames []string, concurrency int, opts UpdateOptions) (w UpdateWriter, err error) {
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return
+	}
+	w = newUpdateWriter(dynamodb.New(sess), tableName, keyNames, concurrency, opts)
+	return
+}
+
+func newUpdateWriter(client dynamodbiface.DynamoDBAPI, tableName string, keyNames []string, concurrency int, opts UpdateOptions) UpdateWriter {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	w := UpdateWriter{
+		client:      client,
+		tableName:   tableName,
+		concurrency: concurrency,
+		keyNames:    map[string]bool{},
+		addFields:   map[string]bool{},
+		removeEmpty: opts.RemoveEmpty,
+	}
+	for _, k := range keyNames {
+		w.keyNames[k] = true
+	}
+	for _, f := range opts.AddFields {
+		w.addFields[f] = true
+	}
+	return w
+}
+
+// UpdateWriter writes to DynamoDB tables using UpdateItem.
+type UpdateWriter struct {
+	client      dynamodbiface.DynamoDBAPI
+	tableName   string
+	concurrency int
+	keyNames    map[string]bool
+	addFields   map[string]bool
+	removeEmpty bool
+}
+
+// Write the records to DynamoDB, updating only the attributes present in each record.
+func (w UpdateWriter) Write(records []map[string]*dynamodb.AttributeValue) (err error) {
+	return parallel(records, w.concurrency, w.update)
+}
+
+func (w UpdateWriter) update(record map[string]*dynamodb.AttributeValue) (err error) {
+	_, err = w.client.UpdateItem(w.updateItemInput(record))
+	if err != nil {
+		return fmt.Errorf("batchwriter: update failed: %w", err)
+	}
+	return
+}
+
+func (w UpdateWriter) updateItemInput(record map[string]*dynamodb.AttributeValue) (uii *dynamodb.UpdateItemInput) {
+	uii = &dynamodb.UpdateItemInput{
+		TableName: aws.String(w.tableName),
+		Key:       map[string]*dynamodb.AttributeValue{},
+	}
+	attributeNames := make([]string, 0, len(record))
+	for name, value := range record {
+		if w.keyNames[name] {
+			uii.Key[name] = value
+			continue
+		}
+		attributeNames = append(attributeNames, name)
+	}
+	sort.Strings(attributeNames)
+
+	var set, add, remove []string
+	names := map[string]*string{}
+	values := map[string]*dynamodb.AttributeValue{}
+	for i, name := range attributeNames {
+		value := record[name]
+		namePlaceholder, valuePlaceholder := fmt.Sprintf("#a%d", i), fmt.Sprintf(":v%d", i)
+		switch {
+		case value.NULL != nil && *value.NULL:
+			if !w.removeEmpty {
+				continue
+			}
+			remove = append(remove, namePlaceholder)
+		case w.addFields[name]:
+			add = append(add, namePlaceholder+" "+valuePlaceholder)
+			values[valuePlaceholder] = value
+		default:
+			set = append(set, namePlaceholder+" = "+valuePlaceholder)
+			values[valuePlaceholder] = value
+		}
+		names[namePlaceholder] = aws.String(name)
+	}
+
+	var clauses []string
+	if len(set) > 0 {
+		clauses = append(clauses, "SET "+strings.Join(set, ", "))
+	}
+	if len(add) > 0 {
+		clauses = append(clauses, "ADD "+strings.Join(add, ", "))
+	}
+	if len(remove) > 0 {
+		clauses = append(clauses, "REMOVE "+strings.Join(remove, ", "))
+	}
+	if len(clauses) == 0 {
+		// Only the key is present, so the item is created if it doesn't exist.
+		return
+	}
+	uii.UpdateExpression = aws.String(strings.Join(clauses, " "))
+	uii.ExpressionAttributeNames = names
+	if len(values) > 0 {
+		uii.ExpressionAttributeValues = values
+	}
+	return
+}
@@ -0,0 +1,100 @@
+package batchwriter
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUpdateItemInput(t *testing.T) {
+	null := &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+	var tests = []struct {
+		name     string
+		opts     UpdateOptions
+		record   map[string]*dynamodb.AttributeValue
+		expected *dynamodb.UpdateItemInput
+	}{
+		{
+			name: "non-key attributes are set",
+			record: map[string]*dynamodb.AttributeValue{
+				"id":   {S: aws.String("123")},
+				"name": {S: aws.String("Alice")},
+				"age":  {N: aws.String("42")},
+			},
+			expected: &dynamodb.UpdateItemInput{
+				TableName:        aws.String("table"),
+				Key:              map[string]*dynamodb.AttributeValue{"id": {S: aws.String("123")}},
+				UpdateExpression: aws.String("SET #a0 = :v0, #a1 = :v1"),
+				ExpressionAttributeNames: map[string]*string{
+					"#a0": aws.String("age"),
+					"#a1": aws.String("name"),
+				},
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+					":v0": {N: aws.String("42")},
+					":v1": {S: aws.String("Alice")},
+				},
+			},
+		},
+		{
+			name: "add fields are added to the existing value",
+			opts: UpdateOptions{AddFields: []string{"count"}},
+			record: map[string]*dynamodb.AttributeValue{
+				"id":    {S: aws.String("123")},
+				"count": {N: aws.String("1")},
+				"name":  {S: aws.String("Alice")},
+			},
+			expected: &dynamodb.UpdateItemInput{
+				TableName:        aws.String("table"),
+				Key:              map[string]*dynamodb.AttributeValue{"id": {S: aws.String("123")}},
+				UpdateExpression: aws.String("SET #a1 = :v1 ADD #a0 :v0"),
+				ExpressionAttributeNames: map[string]*string{
+					"#a0": aws.String("count"),
+					"#a1": aws.String("name"),
+				},
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+					":v0": {N: aws.String("1")},
+					":v1": {S: aws.String("Alice")},
+				},
+			},
+		},
+		{
+			name: "null values are removed when configured",
+			opts: UpdateOptions{RemoveEmpty: true},
+			record: map[string]*dynamodb.AttributeValue{
+				"id":   {S: aws.String("123")},
+				"name": null,
+			},
+			expected: &dynamodb.UpdateItemInput{
+				TableName:        aws.String("table"),
+				Key:              map[string]*dynamodb.AttributeValue{"id": {S: aws.String("123")}},
+				UpdateExpression: aws.String("REMOVE #a0"),
+				ExpressionAttributeNames: map[string]*string{
+					"#a0": aws.String("name"),
+				},
+			},
+		},
+		{
+			name: "null values are ignored by default",
+			record: map[string]*dynamodb.AttributeValue{
+				"id":   {S: aws.String("123")},
+				"name": null,
+			},
+			expected: &dynamodb.UpdateItemInput{
+				TableName: aws.String("table"),
+				Key:       map[string]*dynamodb.AttributeValue{"id": {S: aws.String("123")}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			w := newUpdateWriter(nil, "table", []string{"id"}, 1, tt.opts)
+			actual := w.updateItemInput(tt.record)
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
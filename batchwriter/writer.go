@@ -4,13 +4,34 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
+// Modes of writing to DynamoDB.
+const (
+	// ModePut overwrites existing items using BatchWriteItem.
+	ModePut = "put"
+	// ModePutIfNotExists skips items that already exist in the table.
+	ModePutIfNotExists = "putIfNotExists"
+	// ModeUpdate merges the attributes of each record into existing items.
+	ModeUpdate = "update"
+	// ModeDelete deletes the items identified by the key attributes of each record.
+	ModeDelete = "delete"
+	// ModeTransaction writes batches of records using TransactWriteItems.
+	ModeTransaction = "transaction"
+)
+
+// Writer writes records to DynamoDB.
+type Writer interface {
+	Write(records []map[string]*dynamodb.AttributeValue) error
+}
+
 // New creates a new BatchWriter to write to a DynamoDB table in batches.
 // It uses the default Backoff implementation which provides up to 7 retries
 // costing 25 seconds of latency before failing the entire batch.
@@ -27,10 +48,27 @@ func New(region, tableName string) (bw BatchWriter, err error) {
 	return
 }
 
+// NewForMode creates the Writer for one of the non-transactional modes. keyNames are the names of the
+// table's key attributes, and up to concurrency requests are executed in parallel by the modes that
+// write each item separately.
+func NewForMode(mode, region, tableName string, keyNames []string, concurrency int, opts UpdateOptions) (w Writer, err error) {
+	switch mode {
+	case ModePut:
+		return New(region, tableName)
+	case ModePutIfNotExists:
+		return NewConditionalPutWriter(region, tableName, keyNames, concurrency)
+	case ModeUpdate:
+		return NewUpdateWriter(region, tableName, keyNames, concurrency, opts)
+	case ModeDelete:
+		return NewDeleteWriter(region, tableName, keyNames)
+	}
+	return nil, fmt.Errorf("batchwriter: unknown mode %q", mode)
+}
+
 // BatchWriter writes to DynamoDB tables using BatchWriteItem.
 type BatchWriter struct {
 	Backoff   Backoff
-	client    *dynamodb.DynamoDB
+	client    dynamodbiface.DynamoDBAPI
 	tableName string
 }
 
@@ -50,6 +88,56 @@ func (bw BatchWriter) Write(records []map[string]*dynamodb.AttributeValue) (err
 	return bw.write(requestItems, 0)
 }
 
+// WriteTables writes records to multiple DynamoDB tables using BatchWriteItem, combining the
+// records for different tables into batches of up to 25 requests.
+func (bw BatchWriter) WriteTables(tableToRecords map[string][]map[string]*dynamodb.AttributeValue) (err error) {
+	tableNames := make([]string, 0, len(tableToRecords))
+	for tableName := range tableToRecords {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+	requestItems := map[string][]*dynamodb.WriteRequest{}
+	var count int
+	for _, tableName := range tableNames {
+		for _, record := range tableToRecords[tableName] {
+			requestItems[tableName] = append(requestItems[tableName], &dynamodb.WriteRequest{
+				PutRequest: &dynamodb.PutRequest{
+					Item: record,
+				},
+			})
+			count++
+			if count < 25 {
+				continue
+			}
+			if err = bw.write(requestItems, 0); err != nil {
+				return
+			}
+			requestItems = map[string][]*dynamodb.WriteRequest{}
+			count = 0
+		}
+	}
+	if count > 0 {
+		err = bw.write(requestItems, 0)
+	}
+	return
+}
+
+// Delete from DynamoDB using BatchWriteItem. Each record must contain only the key attributes.
+func (bw BatchWriter) Delete(keys []map[string]*dynamodb.AttributeValue) (err error) {
+	writeRequests := make([]*dynamodb.WriteRequest, len(keys))
+	for i := 0; i < len(keys); i++ {
+		writeRequests[i] = &dynamodb.WriteRequest{
+			DeleteRequest: &dynamodb.DeleteRequest{
+				Key: keys[i],
+			},
+		}
+	}
+	requestItems := map[string][]*dynamodb.WriteRequest{
+		bw.tableName: writeRequests,
+	}
+	return bw.write(requestItems, 0)
+}
+
 func (bw BatchWriter) write(ri map[string][]*dynamodb.WriteRequest, retry int) (err error) {
 	bwo, err := bw.client.BatchWriteItem(&dynamodb.BatchWriteItemInput{
 		RequestItems: ri,
@@ -1,9 +1,13 @@
 package batchwriter
 
 import (
+	"fmt"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
 
 func TestBackoffValues(t *testing.T) {
@@ -45,3 +49,34 @@ func within(actual, expected, tolerance time.Duration) bool {
 	max := expected + tolerance
 	return actual >= min && actual <= max
 }
+
+func TestWriteTables(t *testing.T) {
+	client := &mockBatchWriter{}
+	bw := BatchWriter{Backoff: NewBackoff(1), client: client, tableName: "main"}
+	records := func(n int) (records []map[string]*dynamodb.AttributeValue) {
+		for i := 0; i < n; i++ {
+			records = append(records, map[string]*dynamodb.AttributeValue{"id": {S: aws.String(fmt.Sprint(i))}})
+		}
+		return
+	}
+	err := bw.WriteTables(map[string][]map[string]*dynamodb.AttributeValue{
+		"main":   records(20),
+		"lookup": records(10),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.inputs) != 2 {
+		t.Fatalf("expected 2 batches, got %d", len(client.inputs))
+	}
+	// Tables are written in name order, so the first batch contains all of the lookup table's
+	// records, and the first 15 of the main table's records.
+	first := client.inputs[0].RequestItems
+	if len(first["lookup"]) != 10 || len(first["main"]) != 15 {
+		t.Errorf("expected 10 lookup and 15 main requests in the first batch, got %d and %d", len(first["lookup"]), len(first["main"]))
+	}
+	second := client.inputs[1].RequestItems
+	if len(second["lookup"]) != 0 || len(second["main"]) != 5 {
+		t.Errorf("expected 5 main requests in the second batch, got %d lookup and %d main", len(second["lookup"]), len(second["main"]))
+	}
+}
@@ -0,0 +1,286 @@
+package capacity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling/applicationautoscalingiface"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// ErrOnDemand is returned when the capacity of a table that uses on-demand capacity is raised.
+var ErrOnDemand = errors.New("capacity: the table uses on-demand capacity")
+
+// Throughput is the provisioned capacity of a table or global secondary index.
+type Throughput struct {
+	// IndexName is empty for the table.
+	IndexName string
+	Read      int64
+	Write     int64
+}
+
+// Settings are the capacity settings of a table and its global secondary indexes.
+type Settings struct {
+	TableName string
+	// Throughput of the table, followed by its global secondary indexes.
+	Throughput []Throughput
+	// ScalableTargets are the write capacity auto-scaling settings of the table and its indexes.
+	ScalableTargets []*applicationautoscaling.ScalableTarget
+}
+
+// Raiser raises the write capacity of tables, and restores it afterwards.
+type Raiser struct {
+	client      dynamodbiface.DynamoDBAPI
+	autoScaling applicationautoscalingiface.ApplicationAutoScalingAPI
+	now         func() time.Time
+}
+
+// New creates a Raiser for tables in the region.
+func New(region string) (r Raiser, err error) {
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return
+	}
+	return newRaiser(dynamodb.New(sess), applicationautoscaling.New(sess)), nil
+}
+
+func newRaiser(client dynamodbiface.DynamoDBAPI, autoScaling applicationautoscalingiface.ApplicationAutoScalingAPI) Raiser {
+	return Raiser{
+		client:      client,
+		autoScaling: autoScaling,
+		now:         time.Now,
+	}
+}
+
+// Raise the write capacity of the table and its global secondary indexes to at least writeCapacity,
+// raising the minimum capacity of any auto-scaling targets to match.
+// The original settings are returned, even if an error occurs part way through, so that they can be
+// passed to Restore. Capacity is only raised if it can be decreased again today.
+func (r Raiser) Raise(tableName string, writeCapacity int64) (original Settings, err error) {
+	original.TableName = tableName
+	dto, err := r.client.DescribeTable(&dynamodb.DescribeTableInput{
+		TableName: aws.String(tableName),
+	})
+	if err != nil {
+		err = fmt.Errorf("capacity: failed to describe table %q: %w", tableName, err)
+		return
+	}
+	if bms := dto.Table.BillingModeSummary; bms != nil && aws.StringValue(bms.BillingMode) == dynamodb.BillingModePayPerRequest {
+		err = ErrOnDemand
+		return
+	}
+
+	// Record the current settings.
+	ptds := []*dynamodb.ProvisionedThroughputDescription{dto.Table.ProvisionedThroughput}
+	resourceIDs := []*string{aws.String(resourceID(tableName, ""))}
+	original.Throughput = append(original.Throughput, throughput("", dto.Table.ProvisionedThroughput))
+	for _, gsi := range dto.Table.GlobalSecondaryIndexes {
+		ptds = append(ptds, gsi.ProvisionedThroughput)
+		resourceIDs = append(resourceIDs, aws.String(resourceID(tableName, *gsi.IndexName)))
+		original.Throughput = append(original.Throughput, throughput(*gsi.IndexName, gsi.ProvisionedThroughput))
+	}
+	err = r.autoScaling.DescribeScalableTargetsPages(&applicationautoscaling.DescribeScalableTargetsInput{
+		ServiceNamespace: aws.String(applicationautoscaling.ServiceNamespaceDynamodb),
+		ResourceIds:      resourceIDs,
+	}, func(output *applicationautoscaling.DescribeScalableTargetsOutput, lastPage bool) bool {
+		for _, st := range output.ScalableTargets {
+			if isWriteCapacity(st) {
+				original.ScalableTargets = append(original.ScalableTargets, st)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		err = fmt.Errorf("capacity: failed to describe auto-scaling targets of table %q: %w", tableName, err)
+		return
+	}
+
+	// Check that the capacity can be restored.
+	for i, t := range original.Throughput {
+		if t.Write < writeCapacity && !canDecrease(ptds[i], r.now()) {
+			err = fmt.Errorf("capacity: %s has no decreases left today, so capacity would not be restored", name(tableName, t.IndexName))
+			return
+		}
+	}
+
+	// Stop auto-scaling from scaling in during the import.
+	for _, st := range original.ScalableTargets {
+		_, err = r.autoScaling.RegisterScalableTarget(&applicationautoscaling.RegisterScalableTargetInput{
+			ServiceNamespace:  st.ServiceNamespace,
+			ResourceId:        st.ResourceId,
+			ScalableDimension: st.ScalableDimension,
+			MinCapacity:       aws.Int64(max(aws.Int64Value(st.MinCapacity), writeCapacity)),
+			MaxCapacity:       aws.Int64(max(aws.Int64Value(st.MaxCapacity), writeCapacity)),
+		})
+		if err != nil {
+			err = fmt.Errorf("capacity: failed to update auto-scaling target %q: %w", aws.StringValue(st.ResourceId), err)
+			return
+		}
+	}
+
+	raised := make([]Throughput, len(original.Throughput))
+	for i, t := range original.Throughput {
+		raised[i] = t
+		raised[i].Write = max(t.Write, writeCapacity)
+	}
+	err = r.update(tableName, original.Throughput, raised)
+	return
+}
+
+// Restore the capacity settings of a table.
+func (r Raiser) Restore(original Settings) (err error) {
+	var errs []string
+	// Restore auto-scaling first, so that it doesn't raise capacity again.
+	for _, st := range original.ScalableTargets {
+		_, err = r.autoScaling.RegisterScalableTarget(&applicationautoscaling.RegisterScalableTargetInput{
+			ServiceNamespace:  st.ServiceNamespace,
+			ResourceId:        st.ResourceId,
+			ScalableDimension: st.ScalableDimension,
+			MinCapacity:       st.MinCapacity,
+			MaxCapacity:       st.MaxCapacity,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to restore auto-scaling target %q: %v", aws.StringValue(st.ResourceId), err))
+		}
+	}
+	if len(original.Throughput) > 0 {
+		// The table can't be updated until the previous update is complete.
+		err = r.client.WaitUntilTableExists(&dynamodb.DescribeTableInput{
+			TableName: aws.String(original.TableName),
+		})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed waiting for table %q to become active: %v", original.TableName, err))
+		}
+		var dto *dynamodb.DescribeTableOutput
+		dto, err = r.client.DescribeTable(&dynamodb.DescribeTableInput{
+			TableName: aws.String(original.TableName),
+		})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to describe table %q: %v", original.TableName, err))
+		} else {
+			current := []Throughput{throughput("", dto.Table.ProvisionedThroughput)}
+			for _, gsi := range dto.Table.GlobalSecondaryIndexes {
+				current = append(current, throughput(*gsi.IndexName, gsi.ProvisionedThroughput))
+			}
+			if err = r.update(original.TableName, current, original.Throughput); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("capacity: failed to restore table %q: %s", original.TableName, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// update the write capacity of the table and indexes that differ from the current capacity, and
+// wait for the update to complete.
+func (r Raiser) update(tableName string, current, desired []Throughput) (err error) {
+	byName := make(map[string]Throughput, len(current))
+	for _, t := range current {
+		byName[t.IndexName] = t
+	}
+	uti := &dynamodb.UpdateTableInput{
+		TableName: aws.String(tableName),
+	}
+	var changed bool
+	for _, t := range desired {
+		c, ok := byName[t.IndexName]
+		if !ok || c.Write == t.Write {
+			continue
+		}
+		changed = true
+		// Read capacity is left as-is, since it may have been changed by auto-scaling.
+		pt := &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(c.Read),
+			WriteCapacityUnits: aws.Int64(t.Write),
+		}
+		if t.IndexName == "" {
+			uti.ProvisionedThroughput = pt
+			continue
+		}
+		uti.GlobalSecondaryIndexUpdates = append(uti.GlobalSecondaryIndexUpdates, &dynamodb.GlobalSecondaryIndexUpdate{
+			Update: &dynamodb.UpdateGlobalSecondaryIndexAction{
+				IndexName:             aws.String(t.IndexName),
+				ProvisionedThroughput: pt,
+			},
+		})
+	}
+	if !changed {
+		return
+	}
+	if _, err = r.client.UpdateTable(uti); err != nil {
+		return fmt.Errorf("capacity: failed to update table %q: %w", tableName, err)
+	}
+	err = r.client.WaitUntilTableExists(&dynamodb.DescribeTableInput{
+		TableName: aws.String(tableName),
+	})
+	if err != nil {
+		err = fmt.Errorf("capacity: failed waiting for table %q to become active: %w", tableName, err)
+	}
+	return
+}
+
+// canDecrease returns true if the provisioned throughput can be decreased, see
+// https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/ServiceQuotas.html#default-limits-throughput
+// Up to 4 decreases are allowed at any time during a UTC day, followed by 1 per hour, up to 27 per day.
+func canDecrease(ptd *dynamodb.ProvisionedThroughputDescription, now time.Time) bool {
+	if ptd == nil || ptd.LastDecreaseDateTime == nil {
+		return true
+	}
+	last := ptd.LastDecreaseDateTime.UTC()
+	now = now.UTC()
+	if last.YearDay() != now.YearDay() || last.Year() != now.Year() {
+		return true
+	}
+	decreases := aws.Int64Value(ptd.NumberOfDecreasesToday)
+	if decreases < 4 {
+		return true
+	}
+	if decreases >= 27 {
+		return false
+	}
+	return now.Sub(last) >= time.Hour
+}
+
+func throughput(indexName string, ptd *dynamodb.ProvisionedThroughputDescription) (t Throughput) {
+	t.IndexName = indexName
+	if ptd != nil {
+		t.Read = aws.Int64Value(ptd.ReadCapacityUnits)
+		t.Write = aws.Int64Value(ptd.WriteCapacityUnits)
+	}
+	return
+}
+
+func isWriteCapacity(st *applicationautoscaling.ScalableTarget) bool {
+	d := aws.StringValue(st.ScalableDimension)
+	return d == applicationautoscaling.ScalableDimensionDynamodbTableWriteCapacityUnits ||
+		d == applicationautoscaling.ScalableDimensionDynamodbIndexWriteCapacityUnits
+}
+
+func resourceID(tableName, indexName string) string {
+	if indexName == "" {
+		return "table/" + tableName
+	}
+	return "table/" + tableName + "/index/" + indexName
+}
+
+func name(tableName, indexName string) string {
+	if indexName == "" {
+		return fmt.Sprintf("table %q", tableName)
+	}
+	return fmt.Sprintf("index %q of table %q", indexName, tableName)
+}
+
+func max(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
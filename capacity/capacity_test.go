@@ -0,0 +1,232 @@
+package capacity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling/applicationautoscalingiface"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/google/go-cmp/cmp"
+)
+
+type mockDynamoDB struct {
+	dynamodbiface.DynamoDBAPI
+	table   *dynamodb.TableDescription
+	updates []*dynamodb.UpdateTableInput
+}
+
+func (m *mockDynamoDB) DescribeTable(*dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+	return &dynamodb.DescribeTableOutput{Table: m.table}, nil
+}
+
+func (m *mockDynamoDB) UpdateTable(input *dynamodb.UpdateTableInput) (*dynamodb.UpdateTableOutput, error) {
+	m.updates = append(m.updates, input)
+	// Apply the update, so that it can be restored.
+	if input.ProvisionedThroughput != nil {
+		m.table.ProvisionedThroughput.WriteCapacityUnits = input.ProvisionedThroughput.WriteCapacityUnits
+	}
+	for _, u := range input.GlobalSecondaryIndexUpdates {
+		for _, gsi := range m.table.GlobalSecondaryIndexes {
+			if *gsi.IndexName == *u.Update.IndexName {
+				gsi.ProvisionedThroughput.WriteCapacityUnits = u.Update.ProvisionedThroughput.WriteCapacityUnits
+			}
+		}
+	}
+	return &dynamodb.UpdateTableOutput{}, nil
+}
+
+func (m *mockDynamoDB) WaitUntilTableExists(*dynamodb.DescribeTableInput) error {
+	return nil
+}
+
+type mockAutoScaling struct {
+	applicationautoscalingiface.ApplicationAutoScalingAPI
+	targets    []*applicationautoscaling.ScalableTarget
+	registered []*applicationautoscaling.RegisterScalableTargetInput
+}
+
+func (m *mockAutoScaling) DescribeScalableTargetsPages(input *applicationautoscaling.DescribeScalableTargetsInput, f func(*applicationautoscaling.DescribeScalableTargetsOutput, bool) bool) error {
+	f(&applicationautoscaling.DescribeScalableTargetsOutput{ScalableTargets: m.targets}, true)
+	return nil
+}
+
+func (m *mockAutoScaling) RegisterScalableTarget(input *applicationautoscaling.RegisterScalableTargetInput) (*applicationautoscaling.RegisterScalableTargetOutput, error) {
+	m.registered = append(m.registered, input)
+	return &applicationautoscaling.RegisterScalableTargetOutput{}, nil
+}
+
+func provisionedTable() *dynamodb.TableDescription {
+	return &dynamodb.TableDescription{
+		ProvisionedThroughput: &dynamodb.ProvisionedThroughputDescription{
+			ReadCapacityUnits:  aws.Int64(5),
+			WriteCapacityUnits: aws.Int64(10),
+		},
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndexDescription{
+			{
+				IndexName: aws.String("byCount"),
+				ProvisionedThroughput: &dynamodb.ProvisionedThroughputDescription{
+					ReadCapacityUnits:  aws.Int64(1),
+					WriteCapacityUnits: aws.Int64(2000),
+				},
+			},
+		},
+	}
+}
+
+func TestRaiseAndRestore(t *testing.T) {
+	client := &mockDynamoDB{table: provisionedTable()}
+	autoScaling := &mockAutoScaling{
+		targets: []*applicationautoscaling.ScalableTarget{
+			{
+				ServiceNamespace:  aws.String("dynamodb"),
+				ResourceId:        aws.String("table/test"),
+				ScalableDimension: aws.String("dynamodb:table:ReadCapacityUnits"),
+				MinCapacity:       aws.Int64(5),
+				MaxCapacity:       aws.Int64(100),
+			},
+			{
+				ServiceNamespace:  aws.String("dynamodb"),
+				ResourceId:        aws.String("table/test"),
+				ScalableDimension: aws.String("dynamodb:table:WriteCapacityUnits"),
+				MinCapacity:       aws.Int64(10),
+				MaxCapacity:       aws.Int64(100),
+			},
+		},
+	}
+	r := newRaiser(client, autoScaling)
+
+	original, err := r.Raise("test", 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedOriginal := []Throughput{
+		{Read: 5, Write: 10},
+		{IndexName: "byCount", Read: 1, Write: 2000},
+	}
+	if diff := cmp.Diff(expectedOriginal, original.Throughput); diff != "" {
+		t.Error(diff)
+	}
+	// Only the write capacity auto-scaling target is changed.
+	expectedRegistered := []*applicationautoscaling.RegisterScalableTargetInput{
+		{
+			ServiceNamespace:  aws.String("dynamodb"),
+			ResourceId:        aws.String("table/test"),
+			ScalableDimension: aws.String("dynamodb:table:WriteCapacityUnits"),
+			MinCapacity:       aws.Int64(1000),
+			MaxCapacity:       aws.Int64(1000),
+		},
+	}
+	if diff := cmp.Diff(expectedRegistered, autoScaling.registered); diff != "" {
+		t.Error(diff)
+	}
+	// The index already has enough capacity, so only the table is updated.
+	expectedUpdates := []*dynamodb.UpdateTableInput{
+		{
+			TableName: aws.String("test"),
+			ProvisionedThroughput: &dynamodb.ProvisionedThroughput{
+				ReadCapacityUnits:  aws.Int64(5),
+				WriteCapacityUnits: aws.Int64(1000),
+			},
+		},
+	}
+	if diff := cmp.Diff(expectedUpdates, client.updates); diff != "" {
+		t.Error(diff)
+	}
+
+	autoScaling.registered = nil
+	client.updates = nil
+	if err = r.Restore(original); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedRegistered[0].MinCapacity = aws.Int64(10)
+	expectedRegistered[0].MaxCapacity = aws.Int64(100)
+	if diff := cmp.Diff(expectedRegistered, autoScaling.registered); diff != "" {
+		t.Error(diff)
+	}
+	expectedUpdates[0].ProvisionedThroughput.WriteCapacityUnits = aws.Int64(10)
+	if diff := cmp.Diff(expectedUpdates, client.updates); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestRaiseOnDemand(t *testing.T) {
+	client := &mockDynamoDB{table: &dynamodb.TableDescription{
+		BillingModeSummary: &dynamodb.BillingModeSummary{BillingMode: aws.String("PAY_PER_REQUEST")},
+	}}
+	_, err := newRaiser(client, &mockAutoScaling{}).Raise("test", 1000)
+	if err != ErrOnDemand {
+		t.Errorf("expected ErrOnDemand, got %v", err)
+	}
+}
+
+func TestRaiseWithoutDecreasesLeft(t *testing.T) {
+	now := time.Date(2020, 7, 1, 12, 0, 0, 0, time.UTC)
+	table := provisionedTable()
+	table.ProvisionedThroughput.NumberOfDecreasesToday = aws.Int64(4)
+	table.ProvisionedThroughput.LastDecreaseDateTime = aws.Time(now.Add(-time.Minute))
+	client := &mockDynamoDB{table: table}
+	r := newRaiser(client, &mockAutoScaling{})
+	r.now = func() time.Time { return now }
+	if _, err := r.Raise("test", 1000); err == nil {
+		t.Error("expected an error")
+	}
+	if len(client.updates) > 0 {
+		t.Error("expected the table not to be updated")
+	}
+}
+
+func TestCanDecrease(t *testing.T) {
+	now := time.Date(2020, 7, 1, 12, 0, 0, 0, time.UTC)
+	var tests = []struct {
+		name         string
+		decreases    int64
+		lastDecrease time.Time
+		expected     bool
+	}{
+		{
+			name:         "the first 4 decreases can be made at any time",
+			decreases:    3,
+			lastDecrease: now.Add(-time.Minute),
+			expected:     true,
+		},
+		{
+			name:         "further decreases require an hour since the last decrease",
+			decreases:    4,
+			lastDecrease: now.Add(-time.Minute),
+			expected:     false,
+		},
+		{
+			name:         "further decreases are allowed after an hour",
+			decreases:    4,
+			lastDecrease: now.Add(-time.Hour),
+			expected:     true,
+		},
+		{
+			name:         "there are at most 27 decreases per day",
+			decreases:    27,
+			lastDecrease: now.Add(-2 * time.Hour),
+			expected:     false,
+		},
+		{
+			name:         "decreases made on previous days don't count",
+			decreases:    27,
+			lastDecrease: now.Add(-24 * time.Hour),
+			expected:     true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ptd := &dynamodb.ProvisionedThroughputDescription{
+				NumberOfDecreasesToday: aws.Int64(tt.decreases),
+				LastDecreaseDateTime:   aws.Time(tt.lastDecrease),
+			}
+			if actual := canDecrease(ptd, now); actual != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, actual)
+			}
+		})
+	}
+}
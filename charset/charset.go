@@ -0,0 +1,101 @@
+package charset
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Names of the supported character encodings.
+const (
+	UTF8        = "utf-8"
+	Latin1      = "latin1"
+	Windows1252 = "windows-1252"
+	UTF16LE     = "utf-16le"
+	UTF16BE     = "utf-16be"
+)
+
+// Charset is the character encoding of a source file. The zero value is UTF-8.
+type Charset struct {
+	name     string
+	encoding encoding.Encoding
+	// wide encodings use 2 bytes for each code unit, including new lines.
+	wide      bool
+	bigEndian bool
+}
+
+// Parse the name of a character encoding. An empty name is UTF-8.
+func Parse(name string) (c Charset, err error) {
+	switch strings.ToLower(name) {
+	case "", UTF8, "utf8":
+		return Charset{}, nil
+	case Latin1, "iso-8859-1":
+		return Charset{name: Latin1, encoding: charmap.ISO8859_1}, nil
+	case Windows1252, "cp1252":
+		return Charset{name: Windows1252, encoding: charmap.Windows1252}, nil
+	case UTF16LE:
+		// Byte order marks are decoded as U+FEFF, and removed with the header.
+		return Charset{name: UTF16LE, encoding: unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), wide: true}, nil
+	case UTF16BE:
+		return Charset{name: UTF16BE, encoding: unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), wide: true, bigEndian: true}, nil
+	}
+	return c, fmt.Errorf("charset: unknown encoding %q, expected one of %s, %s, %s, %s or %s", name, UTF8, Latin1, Windows1252, UTF16LE, UTF16BE)
+}
+
+// Name of the encoding.
+func (c Charset) Name() string {
+	if c.name == "" {
+		return UTF8
+	}
+	return c.name
+}
+
+// Wide returns true if new lines are encoded using more than one byte, so a new line can't be found
+// by searching for a byte.
+func (c Charset) Wide() bool {
+	return c.wide
+}
+
+// NewReader returns a reader that converts r to UTF-8.
+func (c Charset) NewReader(r io.Reader) io.Reader {
+	if c.encoding == nil {
+		return r
+	}
+	return transform.NewReader(r, c.encoding.NewDecoder())
+}
+
+// ReadLine reads up to and including the next encoded new line.
+func (c Charset) ReadLine(r *bufio.Reader) (line []byte, err error) {
+	if !c.wide {
+		return r.ReadBytes('\n')
+	}
+	var unit [2]byte
+	for {
+		var n int
+		n, err = io.ReadFull(r, unit[:])
+		line = append(line, unit[:n]...)
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		if err != nil {
+			return
+		}
+		if (c.bigEndian && unit[0] == 0 && unit[1] == '\n') || (!c.bigEndian && unit[0] == '\n' && unit[1] == 0) {
+			return
+		}
+	}
+}
+
+// Decode converts a line to UTF-8.
+func (c Charset) Decode(line []byte) ([]byte, error) {
+	if c.encoding == nil {
+		return line, nil
+	}
+	return c.encoding.NewDecoder().Bytes(line)
+}
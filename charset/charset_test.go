@@ -0,0 +1,129 @@
+package charset
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		input         string
+		expected      string
+		expectedError bool
+	}{
+		{input: "", expected: UTF8},
+		{input: "UTF-8", expected: UTF8},
+		{input: "iso-8859-1", expected: Latin1},
+		{input: "cp1252", expected: Windows1252},
+		{input: "UTF-16LE", expected: UTF16LE},
+		{input: "utf-16be", expected: UTF16BE},
+		{input: "ebcdic", expectedError: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.input, func(t *testing.T) {
+			actual, err := Parse(tt.input)
+			if (err != nil) != tt.expectedError {
+				t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+			}
+			if err == nil && actual.Name() != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, actual.Name())
+			}
+		})
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	var tests = []struct {
+		name     string
+		encoding string
+		input    []byte
+		expected string
+	}{
+		{
+			name:     "UTF-8 is unchanged",
+			encoding: UTF8,
+			input:    []byte("café\n"),
+			expected: "café\n",
+		},
+		{
+			name:     "Latin-1",
+			encoding: Latin1,
+			input:    []byte("caf\xe9\n"),
+			expected: "café\n",
+		},
+		{
+			name:     "Windows-1252 has a euro sign",
+			encoding: Windows1252,
+			input:    []byte("\x80 5\n"),
+			expected: "€ 5\n",
+		},
+		{
+			name:     "UTF-16 little endian",
+			encoding: UTF16LE,
+			input:    []byte{'a', 0, ',', 0, 0xe9, 0, '\n', 0},
+			expected: "a,é\n",
+		},
+		{
+			name:     "UTF-16 big endian",
+			encoding: UTF16BE,
+			input:    []byte{0, 'a', 0, ',', 0, 0xe9, 0, '\n'},
+			expected: "a,é\n",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(tt.encoding)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			actual, err := ioutil.ReadAll(c.NewReader(bytes.NewReader(tt.input)))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, string(actual)); diff != "" {
+				t.Error(diff)
+			}
+			decoded, err := c.Decode(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error decoding: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, string(decoded)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestReadLine(t *testing.T) {
+	c, err := Parse(UTF16LE)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// U+0A0D has a new line as its low byte, but isn't a new line.
+	input := []byte{'a', 0, 0x0d, 0x0a, '\n', 0, 'b', 0}
+	r := bufio.NewReader(bytes.NewReader(input))
+	var lines [][]byte
+	for {
+		line, err := c.ReadLine(r)
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	expected := [][]byte{input[:6], input[6:]}
+	if diff := cmp.Diff(expected, lines); diff != "" {
+		t.Error(diff)
+	}
+}
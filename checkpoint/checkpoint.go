@@ -0,0 +1,164 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Version of a file being imported, which is saved with the ranges so that a checkpoint isn't used
+// to resume the import of a file that has changed.
+type Version struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	// ETag is the entity tag of an S3 object, which changes when its contents change.
+	ETag string `json:"etag,omitempty"`
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d bytes modified at %s", v.Size, v.ModTime.UTC().Format(time.RFC3339Nano))
+	if v.ETag != "" {
+		s += " with ETag " + v.ETag
+	}
+	return s
+}
+
+// Checkpoint is the progress of an import of a version of a file.
+type Checkpoint struct {
+	Version Version `json:"version"`
+	Ranges  []Range `json:"ranges"`
+}
+
+// Range of a file being imported.
+type Range struct {
+	Start int64 `json:"start"`
+	// Offset is the position in the file that all records before have been written.
+	Offset int64 `json:"offset"`
+	End    int64 `json:"end"`
+}
+
+// Complete returns true if all of the records in the range have been written.
+func (r Range) Complete() bool {
+	return r.Offset >= r.End
+}
+
+// Tracker tracks the batches of each range that have been written, so that the offset of each
+// range only moves forward once all of the batches before it have been written.
+type Tracker struct {
+	m       sync.Mutex
+	version Version
+	ranges  []Range
+	pending []*pending
+}
+
+type pending struct {
+	next      int64
+	committed int64
+	batches   map[int64]*batch
+}
+
+type batch struct {
+	offset    int64
+	remaining int
+}
+
+// NewTracker creates a Tracker for the ranges of the version of a file.
+func NewTracker(version Version, ranges []Range) *Tracker {
+	t := &Tracker{
+		version: version,
+		ranges:  append([]Range{}, ranges...),
+		pending: make([]*pending, len(ranges)),
+	}
+	for i := range t.pending {
+		t.pending[i] = &pending{batches: map[int64]*batch{}}
+	}
+	return t
+}
+
+// Add a batch that ends at the offset within the range, which is complete once done has been called
+// by each of the writers that it's sent to.
+func (t *Tracker) Add(rangeIndex int, offset int64, writers int) (done func()) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	p := t.pending[rangeIndex]
+	p.next++
+	id := p.next
+	p.batches[id] = &batch{offset: offset, remaining: writers}
+	return func() {
+		t.done(rangeIndex, id)
+	}
+}
+
+func (t *Tracker) done(rangeIndex int, id int64) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	p := t.pending[rangeIndex]
+	p.batches[id].remaining--
+	for {
+		b, ok := p.batches[p.committed+1]
+		if !ok || b.remaining > 0 {
+			return
+		}
+		p.committed++
+		t.ranges[rangeIndex].Offset = b.offset
+		delete(p.batches, p.committed)
+	}
+}
+
+// Ranges returns the progress of each range.
+func (t *Tracker) Ranges() []Range {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return append([]Range{}, t.ranges...)
+}
+
+// Checkpoint returns the progress of each range, and the version of the file.
+func (t *Tracker) Checkpoint() Checkpoint {
+	return Checkpoint{Version: t.version, Ranges: t.Ranges()}
+}
+
+// Load the ranges from a checkpoint file. If the file doesn't exist, no ranges are returned. An error
+// is returned if the checkpoint is for a different version of the file.
+func Load(name string, version Version) (ranges []Range, err error) {
+	data, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return
+	}
+	var c Checkpoint
+	if err = json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("checkpoint: failed to read %q: %w", name, err)
+	}
+	if c.Version.Size != version.Size || !c.Version.ModTime.Equal(version.ModTime) || c.Version.ETag != version.ETag {
+		return nil, fmt.Errorf("checkpoint: %q is for a file of %v, but the input is %v", name, c.Version, version)
+	}
+	return c.Ranges, nil
+}
+
+// Save the checkpoint to a file. The file is replaced atomically, so that a failure during the save
+// doesn't lose the previous checkpoint.
+func Save(name string, c Checkpoint) (err error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	f, err := ioutil.TempFile(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	return os.Rename(f.Name(), name)
+}
@@ -0,0 +1,85 @@
+package checkpoint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTracker(t *testing.T) {
+	tracker := NewTracker(Version{Size: 600}, []Range{{Start: 0, Offset: 0, End: 300}, {Start: 300, Offset: 300, End: 600}})
+	first := tracker.Add(0, 100, 2)
+	second := tracker.Add(0, 200, 2)
+	other := tracker.Add(1, 400, 1)
+
+	// The second batch completes before the first, so the offset can't move yet.
+	second()
+	second()
+	other()
+	expected := []Range{{Start: 0, Offset: 0, End: 300}, {Start: 300, Offset: 400, End: 600}}
+	if diff := cmp.Diff(expected, tracker.Ranges()); diff != "" {
+		t.Error(diff)
+	}
+
+	// The first batch has only been written by one of the two writers.
+	first()
+	if diff := cmp.Diff(expected, tracker.Ranges()); diff != "" {
+		t.Error(diff)
+	}
+
+	// Once the first batch is written by both writers, the offset moves past both batches.
+	first()
+	expected[0].Offset = 200
+	if diff := cmp.Diff(expected, tracker.Ranges()); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "checkpoint.json")
+
+	version := Version{Size: 200, ModTime: time.Date(2020, 1, 1, 12, 0, 0, 1, time.UTC)}
+	ranges, err := Load(name, version)
+	if err != nil {
+		t.Fatalf("unexpected error loading a missing checkpoint: %v", err)
+	}
+	if ranges != nil {
+		t.Errorf("expected no ranges, got %v", ranges)
+	}
+
+	expected := []Range{{Start: 0, Offset: 50, End: 100}, {Start: 100, Offset: 200, End: 200}}
+	if err = Save(name, Checkpoint{Version: version, Ranges: expected}); err != nil {
+		t.Fatalf("unexpected error saving: %v", err)
+	}
+	actual, err := Load(name, version)
+	if err != nil {
+		t.Fatalf("unexpected error loading: %v", err)
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Error(diff)
+	}
+	if actual[0].Complete() || !actual[1].Complete() {
+		t.Errorf("expected only the second range to be complete")
+	}
+
+	// Checkpoints can't be used if the file has changed.
+	changed := []Version{
+		{Size: 201, ModTime: version.ModTime},
+		{Size: 200, ModTime: version.ModTime.Add(time.Second)},
+		{Size: 200, ModTime: version.ModTime, ETag: "abc"},
+	}
+	for _, v := range changed {
+		if _, err = Load(name, v); err == nil {
+			t.Errorf("expected an error loading the checkpoint for %v", v)
+		}
+	}
+}
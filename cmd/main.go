@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -10,17 +11,36 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/a-h/ddbimport/batchwriter"
+	"github.com/a-h/ddbimport/capacity"
+	"github.com/a-h/ddbimport/charset"
+	"github.com/a-h/ddbimport/checkpoint"
 	"github.com/a-h/ddbimport/csvtodynamo"
+	"github.com/a-h/ddbimport/dedupe"
+	"github.com/a-h/ddbimport/estimate"
+	"github.com/a-h/ddbimport/fixedwidth"
+	"github.com/a-h/ddbimport/jsonltodynamo"
+	"github.com/a-h/ddbimport/local"
 	"github.com/a-h/ddbimport/log"
+	"github.com/a-h/ddbimport/parquettodynamo"
+	"github.com/a-h/ddbimport/route"
+	"github.com/a-h/ddbimport/s3reader"
+	"github.com/a-h/ddbimport/shuffle"
+	"github.com/a-h/ddbimport/sls/linereader"
+	"github.com/a-h/ddbimport/sls/preflight/process"
 	"github.com/a-h/ddbimport/sls/state"
 	_ "github.com/a-h/ddbimport/sls/statik"
+	"github.com/a-h/ddbimport/table"
+	"github.com/a-h/ddbimport/textformat"
 	"github.com/a-h/ddbimport/version"
+	"github.com/a-h/ddbimport/xlsxtodynamo"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
@@ -30,11 +50,12 @@ import (
 	"github.com/google/uuid"
 	"github.com/rakyll/statik/fs"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // Target DynamoDB table.
-var tableRegionFlag = flag.String("tableRegion", "", "The AWS region where the DynamoDB table is located")
-var tableNameFlag = flag.String("tableName", "", "The DynamoDB table name to import to.")
+var tableRegionFlag = flag.String("tableRegion", "", "The AWS region where the DynamoDB table is located. Local imports can write to multiple regions by passing a comma separated list.")
+var tableNameFlag = flag.String("tableName", "", "The DynamoDB table name to import to. Local imports can write to multiple tables by passing a comma separated list, with one table name for each region.")
 
 // Source bucket.
 var bucketRegionFlag = flag.String("bucketRegion", "", "The AWS region where the source bucket is located")
@@ -46,24 +67,49 @@ var inputFileFlag = flag.String("inputFile", "", "The local CSV file to upload t
 
 // Remote configuration.
 var stepFnRegionFlag = flag.String("stepFnRegion", "", "The AWS region of the ddbimport Step Function.")
+var preflightFlag = flag.String("preflight", state.PreflightScan, "How remote imports divide the file into batches. Use 'scan' to read the whole file, or 'sample' to read around evenly spaced offsets, which falls back to a scan if quoted fields make the start of a record ambiguous.")
 var installFlag = flag.Bool("install", false, "Set to install the ddbimport Step Function.")
 var remoteFlag = flag.Bool("remote", false, "Set when the import should be carried out using the ddbimport Step Function.")
 
 // Global configuration.
 var numericFieldsFlag = flag.String("numericFields", "", "A comma separated list of fields that are numeric.")
 var booleanFieldsFlag = flag.String("booleanFields", "", "A comma separated list of fields that are boolean.")
-var delimiterFlag = flag.String("delimiter", "comma", "The delimiter of the CSV file. Use the string 'tab' or 'comma'")
+var delimiterFlag = flag.String("delimiter", "comma", "The delimiter of the CSV file. Use 'comma', 'tab', 'pipe', 'semicolon', 'space', any single character, or an escape sequence such as '\\x1f'.")
+var commentFlag = flag.String("comment", "", "The prefix of lines in the CSV file to ignore, e.g. '#'.")
+var lazyQuotesFlag = flag.Bool("lazyQuotes", false, "Set to allow quotes within unquoted CSV fields, and unescaped quotes within quoted fields. New lines within quoted fields aren't supported, since new lines can't be told apart from the end of a record.")
+var trimLeadingSpaceFlag = flag.Bool("trimLeadingSpace", false, "Set to ignore whitespace at the start of each CSV field.")
+var skipLinesFlag = flag.Int("skipLines", 0, "The number of lines before the CSV header to ignore, e.g. a title.")
+var columnsFlag = flag.String("columns", "", "A comma separated list of column names for CSV files without a header row. If set, the first line is data.")
+var encodingFlag = flag.String("encoding", charset.UTF8, "The character encoding of the file, converted to UTF-8 before it's parsed. Use 'utf-8', 'latin1', 'windows-1252', 'utf-16le' or 'utf-16be'.")
+var formatFlag = flag.String("format", state.FormatCSV, "The format of the file. Use 'csv' for delimited files with a header row, 'jsonl' for files containing a JSON object on each line, 'fixed' for fixed-width files described by the layout flag, 'parquet' for Parquet files, or 'xlsx' for Excel workbooks, which are only supported by local imports.")
+var layoutFlag = flag.String("layout", "", "The fields of a fixed-width file, as a comma separated list of name:start:length, where the first character of the line is at position 1, e.g. 'id:1:8,name:9:20'.")
+var sheetFlag = flag.String("sheet", "", "The name of the sheet of an XLSX file to import, or its position starting at 1. Defaults to the first sheet.")
+var trimFlag = flag.String("trim", textformat.TrimBoth, "How padding spaces are removed from fixed-width fields. Use 'both', 'left', 'right' or 'none'.")
 var concurrencyFlag = flag.Int("concurrency", 8, "Number of imports to execute in parallel.")
-
-func delimiter(s string) rune {
-	if s == "," || s == "\t" {
-		return rune(s[0])
-	}
-	if s == "tab" {
-		return '\t'
-	}
-	return ','
-}
+var modeFlag = flag.String("mode", batchwriter.ModePut, "The write mode. Use 'put' to overwrite existing items, 'putIfNotExists' to skip items that already exist, 'update' to only set the attributes in the file, or 'delete' to delete the items with the keys in the file. Use 'transaction' to import small local files, where either all items are written or none are.")
+var itemConcurrencyFlag = flag.Int("itemConcurrency", 5, "Number of single item requests to execute in parallel for each batch when the write mode doesn't use BatchWriteItem.")
+var addFieldsFlag = flag.String("addFields", "", "A comma separated list of numeric fields that are added to existing values in update mode, e.g. counters.")
+var transactionTokenFlag = flag.String("transactionToken", "", "In transaction mode, the token used to make retries of the same import idempotent for 10 minutes. Defaults to a random value. Can't be used with -rollback.")
+var onDuplicateKeyFlag = flag.String("onDuplicateKey", string(dedupe.LastWins), "How to handle rows with the same key within a batch, since BatchWriteItem rejects them. Use 'lastWins', 'firstWins' or 'error'.")
+var readersFlag = flag.Int("readers", 1, "Number of byte ranges of the input file to read and convert in parallel during a local import.")
+var checkpointFlag = flag.String("checkpoint", "", "A file to save the progress of a local import to. If the file already exists, the import resumes from the progress it contains.")
+var shuffleWindowFlag = flag.Int("shuffleWindow", 0, "Set to buffer this number of rows and interleave them by partition key, so that each batch is spread across partitions when the file is sorted by partition key.")
+var routeFlag = flag.String("route", "", "Rules to route rows, or some of their columns, to additional tables, separated by semicolons. Each rule is in the form table[:columns][?column=value&column=value], e.g. 'lookup:email,id?type=user'. Rows are written to the tableName table too, unless a rule names it.")
+var dryRunFlag = flag.Bool("dryRun", false, "Set to read, convert and validate the file without writing to the table.")
+var dryRunPrintFlag = flag.Int("dryRunPrint", 0, "In a dry run, the number of converted items to print as DynamoDB JSON.")
+var rollbackFlag = flag.Bool("rollback", false, "In transaction mode, set to undo committed transactions if a later transaction fails, by restoring the items that existed before the import and deleting the items it created.")
+var removeEmptyFlag = flag.Bool("removeEmpty", false, "Set to remove attributes from existing items when the value in the file is empty in update mode.")
+var estimateFlag = flag.String("estimate", "", "Set to 'only' to print an estimate of the write units, cost and duration of the import without importing, or 'before' to print the estimate and then import.")
+var estimateJSONFlag = flag.Bool("estimateJSON", false, "Set to print the estimate as JSON instead of text.")
+var estimateSampleFlag = flag.Int("estimateSample", 10000, "The number of records at the start of the file to sample for the estimate.")
+var estimateLatencyFlag = flag.Duration("estimateLatency", 50*time.Millisecond, "The expected duration of each write request, used to estimate the duration of the import.")
+var writeCapacityFlag = flag.Int64("writeCapacity", 0, "The provisioned write capacity of a table created with the createTable flag, also used to estimate the duration of the import. Defaults to the provisioned write capacity of the table.")
+var readCapacityFlag = flag.Int64("readCapacity", 5, "The provisioned read capacity of a table created with the createTable flag.")
+var raiseWriteCapacityFlag = flag.Int64("raiseWriteCapacity", 0, "Set to raise the provisioned write capacity of the table and its global secondary indexes to at least this value during the import. The original capacity and auto-scaling settings are restored afterwards, even if the import fails.")
+var createTableFlag = flag.String("createTable", "", "The keys of the table to create if it doesn't exist, as name:type with the partition key first, e.g. 'ngram:S,year:N'. The import starts once the table is active.")
+var createIndexesFlag = flag.String("createIndexes", "", "The global secondary indexes of a table created with the createTable flag, separated by semicolons, e.g. 'byCount=match_count:N;byYear=year:N,ngram:S'.")
+var billingModeFlag = flag.String("billingMode", dynamodb.BillingModePayPerRequest, "The billing mode of a table created with the createTable flag. Use 'PAY_PER_REQUEST' or 'PROVISIONED', which requires the writeCapacity flag.")
+var writeUnitPriceFlag = flag.Float64("writeUnitPrice", 0.625, "The on-demand price of 1 million write request units in USD, used to estimate the cost of the import.")
 
 func printUsageAndExit(suffix ...string) {
 	fmt.Println("usage: ddbimport [<args>]")
@@ -75,9 +121,60 @@ func printUsageAndExit(suffix ...string) {
 	fmt.Println("Import S3 file from this computer:")
 	fmt.Println("  ddbimport -bucketRegion eu-west-2 -bucketName infinityworks-ddbimport -bucketKey data1M.csv -delimiter tab -numericFields year -tableRegion eu-west-2 -tableName ddbimport")
 	fmt.Println()
+	fmt.Println("Import local CSV from this computer, skipping items that already exist:")
+	fmt.Println("  ddbimport -inputFile ../data.csv -delimiter tab -numericFields year -tableRegion eu-west-2 -tableName ddbimport -mode putIfNotExists")
+	fmt.Println()
+	fmt.Println("Import local CSV exported from a Windows tool with the Windows-1252 encoding:")
+	fmt.Println("  ddbimport -inputFile ../partner.csv -encoding windows-1252 -tableRegion eu-west-2 -tableName ddbimport")
+	fmt.Println()
+	fmt.Println("Import a local fixed-width file, skipping a title line:")
+	fmt.Println("  ddbimport -inputFile ../extract.txt -format fixed -layout id:1:8,name:9:20,balance:29:10 -numericFields id,balance -skipLines 1 -tableRegion eu-west-2 -tableName ddbimport")
+	fmt.Println()
+	fmt.Println("Import a local Parquet file, sharing the rows between 4 readers:")
+	fmt.Println("  ddbimport -inputFile ../events.parquet -format parquet -tableRegion eu-west-2 -tableName ddbimport -readers 4")
+	fmt.Println()
+	fmt.Println("Import the Prices sheet of a local Excel workbook, skipping a title row:")
+	fmt.Println("  ddbimport -inputFile ../prices.xlsx -format xlsx -sheet Prices -skipLines 1 -tableRegion eu-west-2 -tableName ddbimport")
+	fmt.Println()
+	fmt.Println("Update existing items, setting only the columns in the local CSV:")
+	fmt.Println("  ddbimport -inputFile ../data.csv -delimiter tab -numericFields year,page_count -tableRegion eu-west-2 -tableName ddbimport -mode update -addFields page_count")
+	fmt.Println()
+	fmt.Println("Delete the items with the keys in a local JSON Lines file:")
+	fmt.Println("  ddbimport -inputFile ../keys.jsonl -format jsonl -tableRegion eu-west-2 -tableName ddbimport -mode delete")
+	fmt.Println()
+	fmt.Println("Import a small local CSV in transactions, rolling back if any transaction fails:")
+	fmt.Println("  ddbimport -inputFile ../reference.csv -tableRegion eu-west-2 -tableName reference -mode transaction -rollback")
+	fmt.Println()
+	fmt.Println("Create an on-demand table if it doesn't exist, then import local CSV:")
+	fmt.Println("  ddbimport -inputFile ../data.csv -delimiter tab -numericFields year,match_count -tableRegion eu-west-2 -tableName ddbimport -createTable ngram:S,year:N -createIndexes byCount=match_count:N")
+	fmt.Println()
+	fmt.Println("Raise the write capacity of a provisioned table to 2000 during an import of a local CSV:")
+	fmt.Println("  ddbimport -inputFile ../data.csv -delimiter tab -numericFields year -tableRegion eu-west-2 -tableName ddbimport -raiseWriteCapacity 2000")
+	fmt.Println()
+	fmt.Println("Import a local CSV that is sorted by partition key, spreading each batch across partitions:")
+	fmt.Println("  ddbimport -inputFile ../data.csv -delimiter tab -numericFields year -tableRegion eu-west-2 -tableName ddbimport -shuffleWindow 10000")
+	fmt.Println()
+	fmt.Println("Import local CSV, also writing the email and id columns of users to a lookup table:")
+	fmt.Println("  ddbimport -inputFile ../users.csv -numericFields id -tableRegion eu-west-2 -tableName users -route 'usersByEmail:email,id?type=user'")
+	fmt.Println()
+	fmt.Println("Import local CSV to tables in two regions:")
+	fmt.Println("  ddbimport -inputFile ../data.csv -delimiter tab -numericFields year -tableRegion eu-west-2,us-east-1 -tableName ddbimport")
+	fmt.Println()
+	fmt.Println("Import local CSV using 4 readers, saving progress so that the same command resumes the import if it fails:")
+	fmt.Println("  ddbimport -inputFile ../data.csv -delimiter tab -numericFields year -tableRegion eu-west-2 -tableName ddbimport -readers 4 -checkpoint data.checkpoint.json")
+	fmt.Println()
+	fmt.Println("Validate a local CSV without writing to the table, printing the first 10 items:")
+	fmt.Println("  ddbimport -inputFile ../data.csv -delimiter tab -numericFields year -tableRegion eu-west-2 -tableName ddbimport -dryRun -dryRunPrint 10")
+	fmt.Println()
+	fmt.Println("Estimate the cost and duration of importing an S3 file using the remote ddbimport Step Function, without importing:")
+	fmt.Println("  ddbimport -remote -bucketRegion eu-west-2 -bucketName infinityworks-ddbimport -bucketKey data1M.csv -delimiter tab -numericFields year -tableRegion eu-west-2 -tableName ddbimport -estimate only")
+	fmt.Println()
 	fmt.Println("Import S3 file using remote ddbimport Step Function:")
 	fmt.Println("  ddbimport -remote -bucketRegion eu-west-2 -bucketName infinityworks-ddbimport -bucketKey data1M.csv -delimiter tab -numericFields year -tableRegion eu-west-2 -tableName ddbimport")
 	fmt.Println()
+	fmt.Println("Import a large S3 file using remote ddbimport Step Function, sampling the file to start importing sooner:")
+	fmt.Println("  ddbimport -remote -bucketRegion eu-west-2 -bucketName infinityworks-ddbimport -bucketKey data1M.csv -delimiter tab -numericFields year -tableRegion eu-west-2 -tableName ddbimport -preflight sample")
+	fmt.Println()
 	fmt.Println("Install ddbimport Step Function:")
 	fmt.Println("  ddbimport -install -stepFnRegion=eu-west-2")
 	fmt.Println()
@@ -100,7 +197,158 @@ func main() {
 	if *tableRegionFlag == "" || *tableNameFlag == "" {
 		printUsageAndExit("Must include a table region and table name flag.")
 	}
+	targets, err := local.ParseTargets(*tableRegionFlag, *tableNameFlag)
+	if err != nil {
+		printUsageAndExit(err.Error())
+	}
+	tableRegion, tableName := targets[0].Region, targets[0].TableName
+	if len(targets) > 1 {
+		if *remoteFlag && !*dryRunFlag {
+			printUsageAndExit("Multiple tables are not supported by remote imports.")
+		}
+		if *modeFlag == batchwriter.ModeTransaction {
+			printUsageAndExit("Multiple tables are not supported in transaction mode.")
+		}
+		if *routeFlag != "" {
+			printUsageAndExit("Multiple tables are not supported when routing rows to tables.")
+		}
+	}
+	switch *modeFlag {
+	case batchwriter.ModePut, batchwriter.ModePutIfNotExists, batchwriter.ModeUpdate, batchwriter.ModeDelete, batchwriter.ModeTransaction:
+		break
+	default:
+		printUsageAndExit("Unknown mode " + *modeFlag)
+	}
+	if *rollbackFlag && *transactionTokenFlag != "" {
+		// DynamoDB would treat the transactions of a rolled back import as already applied if the
+		// import was run again with the same token, so each import that can roll back uses a new one.
+		printUsageAndExit("The -transactionToken flag can't be used with -rollback.")
+	}
+	switch *formatFlag {
+	case state.FormatCSV, state.FormatJSONLines, state.FormatFixedWidth, state.FormatParquet, state.FormatXLSX:
+		break
+	default:
+		printUsageAndExit("Unknown format " + *formatFlag)
+	}
+	cs, err := charset.Parse(*encodingFlag)
+	if err != nil {
+		printUsageAndExit(err.Error())
+	}
+	source := state.Source{
+		Delimiter:        *delimiterFlag,
+		Format:           *formatFlag,
+		Encoding:         *encodingFlag,
+		Comment:          *commentFlag,
+		LazyQuotes:       *lazyQuotesFlag,
+		TrimLeadingSpace: *trimLeadingSpaceFlag,
+		SkipLines:        *skipLinesFlag,
+		Layout:           *layoutFlag,
+		Trim:             *trimFlag,
+	}
+	dialect, err := source.Dialect()
+	if err != nil {
+		printUsageAndExit(err.Error())
+	}
+	var columns []string
+	if *columnsFlag != "" {
+		if *formatFlag != state.FormatCSV {
+			printUsageAndExit("Columns are only supported by CSV files.")
+		}
+		columns = strings.Split(*columnsFlag, ",")
+		source.Columns = columns
+	}
+	var layout textformat.Layout
+	if *formatFlag == state.FormatFixedWidth {
+		if layout, err = textformat.ParseLayout(*layoutFlag, *trimFlag); err != nil {
+			printUsageAndExit(err.Error())
+		}
+		// Fixed-width files don't have a header, the columns are the fields of the layout.
+		columns = layout.Columns()
+	} else if *layoutFlag != "" {
+		printUsageAndExit("The layout flag is only supported by fixed-width files.")
+	}
+	if *formatFlag == state.FormatParquet || *formatFlag == state.FormatXLSX {
+		if cs.Name() != charset.UTF8 {
+			printUsageAndExit("The encoding flag isn't supported by Parquet or XLSX files.")
+		}
+		if *numericFieldsFlag != "" || *booleanFieldsFlag != "" {
+			printUsageAndExit("The numericFields and booleanFields flags aren't supported by Parquet or XLSX files, since the types of their values are used.")
+		}
+		if *checkpointFlag != "" {
+			printUsageAndExit("Checkpoints are not supported by Parquet or XLSX files.")
+		}
+	}
+	if *formatFlag == state.FormatXLSX {
+		if *remoteFlag && !*dryRunFlag {
+			printUsageAndExit("XLSX files are only supported by local imports.")
+		}
+		if *readersFlag > 1 {
+			printUsageAndExit("XLSX files must be read with a single reader.")
+		}
+	} else if *sheetFlag != "" {
+		printUsageAndExit("The sheet flag is only supported by XLSX files.")
+	}
+	if cs.Wide() && *readersFlag > 1 {
+		printUsageAndExit("Files encoded with UTF-16 must be read with a single reader.")
+	}
+	if *preflightFlag != state.PreflightScan && *preflightFlag != state.PreflightSample {
+		printUsageAndExit("Unknown preflight mode " + *preflightFlag)
+	}
+	if *estimateFlag != "" && *estimateFlag != "only" && *estimateFlag != "before" {
+		printUsageAndExit("Unknown estimate option " + *estimateFlag)
+	}
+	if *checkpointFlag != "" {
+		if *remoteFlag || *modeFlag == batchwriter.ModeTransaction {
+			printUsageAndExit("Checkpoints are only supported by local imports.")
+		}
+		if *shuffleWindowFlag > 0 {
+			printUsageAndExit("Checkpoints are not supported when shuffling rows, because rows are written out of order.")
+		}
+	}
+	var rules []route.Rule
+	if *routeFlag != "" {
+		if *modeFlag != batchwriter.ModePut {
+			printUsageAndExit("Routing rows to tables is only supported in put mode.")
+		}
+		if *remoteFlag && !*dryRunFlag {
+			printUsageAndExit("Routing rows to tables is not supported by remote imports.")
+		}
+		if rules, err = route.Parse(*routeFlag); err != nil {
+			printUsageAndExit(err.Error())
+		}
+		if !hasRule(rules, tableName) {
+			rules = append([]route.Rule{{Table: tableName}}, rules...)
+		}
+	}
+	var createSchema table.Schema
+	if *createTableFlag != "" {
+		if createSchema.Keys, err = table.ParseKeys(*createTableFlag); err != nil {
+			printUsageAndExit(err.Error())
+		}
+		if createSchema.Indexes, err = table.ParseIndexes(*createIndexesFlag); err != nil {
+			printUsageAndExit(err.Error())
+		}
+		switch *billingModeFlag {
+		case dynamodb.BillingModePayPerRequest:
+		case dynamodb.BillingModeProvisioned:
+			if *writeCapacityFlag <= 0 || *readCapacityFlag <= 0 {
+				printUsageAndExit("Provisioned billing mode requires the readCapacity and writeCapacity flags.")
+			}
+			createSchema.ReadCapacityUnits = *readCapacityFlag
+			createSchema.WriteCapacityUnits = *writeCapacityFlag
+		default:
+			printUsageAndExit("Unknown billing mode " + *billingModeFlag)
+		}
+	}
+	onDuplicateKey, err := dedupe.ParsePolicy(*onDuplicateKeyFlag)
+	if err != nil {
+		printUsageAndExit("Unknown onDuplicateKey policy " + *onDuplicateKeyFlag)
+	}
 	numericFields := strings.Split(*numericFieldsFlag, ",")
+	updateOptions := batchwriter.UpdateOptions{
+		AddFields:   strings.Split(*addFieldsFlag, ","),
+		RemoveEmpty: *removeEmptyFlag,
+	}
 	booleanFields := strings.Split(*booleanFieldsFlag, ",")
 	localFile := *inputFileFlag != ""
 	remoteFile := *bucketRegionFlag != "" || *bucketNameFlag != "" || *bucketKeyFlag != ""
@@ -110,30 +358,126 @@ func main() {
 	if remoteFile && (*bucketRegionFlag == "" || *bucketNameFlag == "" || *bucketKeyFlag == "") {
 		printUsageAndExit("Must pass values for all of the bucketRegion, bucketName and bucketKey arguments if a localFile argument is omitted.")
 	}
-	if *remoteFlag {
+	inputName := *inputFileFlag
+	input := func() (io.ReadCloser, error) { return os.Open(*inputFileFlag) }
+	inputSize := func() (int64, error) { return fileSize(*inputFileFlag) }
+	if remoteFile {
+		inputName = fmt.Sprintf("s3://%s/%s (%s)", url.PathEscape(*bucketNameFlag), url.PathEscape(*bucketKeyFlag), *bucketRegionFlag)
+		input = func() (io.ReadCloser, error) { return s3Get(*bucketRegionFlag, *bucketNameFlag, *bucketKeyFlag) }
+		inputSize = func() (int64, error) { return s3Size(*bucketRegionFlag, *bucketNameFlag, *bucketKeyFlag) }
+	}
+	openSource := func() (local.Source, error) {
+		if remoteFile {
+			return local.OpenS3(*bucketRegionFlag, *bucketNameFlag, *bucketKeyFlag)
+		}
+		return local.OpenFile(*inputFileFlag)
+	}
+	// Parquet and XLSX files are read using random access, since their contents are listed at the end
+	// of the file, so nothing is streamed from the input.
+	var random local.Source
+	if *formatFlag == state.FormatParquet || *formatFlag == state.FormatXLSX {
+		if random, err = openSource(); err != nil {
+			log.Default.Fatal("failed to open input file", zap.String("input", inputName), zap.Error(err))
+		}
+		input = random.Opener(0, 0)
+	}
+	o := options{
+		inputName:       inputName,
+		input:           input,
+		inputSize:       inputSize,
+		random:          random,
+		cs:              cs,
+		format:          *formatFlag,
+		numericFields:   numericFields,
+		booleanFields:   booleanFields,
+		columns:         columns,
+		dialect:         dialect,
+		layout:          layout,
+		sheet:           *sheetFlag,
+		targets:         targets,
+		mode:            *modeFlag,
+		concurrency:     *concurrencyFlag,
+		itemConcurrency: *itemConcurrencyFlag,
+		updateOptions:   updateOptions,
+		onDuplicateKey:  onDuplicateKey,
+		shuffleWindow:   *shuffleWindowFlag,
+		rules:           rules,
+	}
+	if *createTableFlag != "" && !*dryRunFlag && *estimateFlag != "only" {
+		for _, t := range targets {
+			createTable(t.Region, t.TableName, createSchema)
+		}
+	}
+	if *estimateFlag != "" && !*dryRunFlag {
+		writeCapacity := *writeCapacityFlag
+		if *raiseWriteCapacityFlag > 0 {
+			writeCapacity = *raiseWriteCapacityFlag
+		}
+		workers, recordsPerRequest := estimateWorkers(o.mode, o.concurrency, o.itemConcurrency)
+		if *remoteFlag {
+			workers *= stepFunctionMaxConcurrency
+		}
+		estimateImport(o, estimate.Input{
+			Workers:                   workers,
+			RecordsPerRequest:         recordsPerRequest,
+			RequestLatency:            *estimateLatencyFlag,
+			ProvisionedWriteCapacity:  writeCapacity,
+			PricePerMillionWriteUnits: *writeUnitPriceFlag,
+		}, *estimateSampleFlag, *estimateJSONFlag)
+		if *estimateFlag == "only" {
+			return
+		}
+	}
+	if *raiseWriteCapacityFlag > 0 && !*dryRunFlag {
+		restorer := newCapacityRestorer()
+		defer restorer.restore()
+		for _, t := range targets {
+			restorer.add(raiseCapacity(t.Region, t.TableName, *raiseWriteCapacityFlag))
+		}
+	}
+	if *remoteFlag && !*dryRunFlag {
 		if !remoteFile {
 			printUsageAndExit("Remote import requires the file to be located within an S3 bucket. Pass the bucketRegion, bucketName and bucketKey arguments.")
 		}
-		stepFnRegion := *tableRegionFlag
+		if *modeFlag == batchwriter.ModeTransaction {
+			printUsageAndExit("Transaction mode is not supported by remote imports.")
+		}
+		stepFnRegion := tableRegion
 		if *stepFnRegionFlag != "" {
 			stepFnRegion = *stepFnRegionFlag
 		}
 		input := state.Input{
 			Source: state.Source{
-				Region:        *bucketRegionFlag,
-				Bucket:        *bucketNameFlag,
-				Key:           *bucketKeyFlag,
-				NumericFields: numericFields,
-				BooleanFields: booleanFields,
-				Delimiter:     string(delimiter(*delimiterFlag)),
+				Region:           *bucketRegionFlag,
+				Bucket:           *bucketNameFlag,
+				Key:              *bucketKeyFlag,
+				NumericFields:    numericFields,
+				BooleanFields:    booleanFields,
+				Delimiter:        string(dialect.Delimiter),
+				Format:           *formatFlag,
+				Encoding:         cs.Name(),
+				Comment:          *commentFlag,
+				LazyQuotes:       dialect.LazyQuotes,
+				TrimLeadingSpace: dialect.TrimLeadingSpace,
+				SkipLines:        dialect.SkipLines,
+				Columns:          columns,
+				Layout:           *layoutFlag,
+				Trim:             *trimFlag,
 			},
 			Configuration: state.Configuration{
 				LambdaConcurrency:     *concurrencyFlag,
 				LambdaDurationSeconds: 900,
+				Mode:                  *modeFlag,
+				ItemConcurrency:       *itemConcurrencyFlag,
+				AddFields:             updateOptions.AddFields,
+				RemoveEmpty:           updateOptions.RemoveEmpty,
+				OnDuplicateKey:        string(onDuplicateKey),
+				ShuffleWindow:         *shuffleWindowFlag,
+				Preflight:             *preflightFlag,
 			},
 			Target: state.Target{
-				Region:    *tableRegionFlag,
-				TableName: *tableNameFlag,
+				Region:    tableRegion,
+				TableName: tableName,
 			},
 		}
 		importRemote(stepFnRegion, input)
@@ -141,18 +485,70 @@ func main() {
 	}
 
 	// Import local.
-	inputName := *inputFileFlag
-	input := func() (io.ReadCloser, error) { return os.Open(*inputFileFlag) }
-	if remoteFile {
-		inputName = fmt.Sprintf("s3://%s/%s (%s)", url.PathEscape(*bucketNameFlag), url.PathEscape(*bucketKeyFlag), *bucketRegionFlag)
-		input = func() (io.ReadCloser, error) { return s3Get(*bucketRegionFlag, *bucketNameFlag, *bucketKeyFlag) }
+	if *dryRunFlag {
+		dryRun(o, *dryRunPrintFlag)
+		return
+	}
+	if *modeFlag == batchwriter.ModeTransaction {
+		token := *transactionTokenFlag
+		if token == "" {
+			token = uuid.New().String()
+		}
+		importLocalTransaction(o, token, *rollbackFlag)
+		return
+	}
+	ranges := []local.Range{{Start: 0, End: -1, Open: input}}
+	var progress *checkpoint.Tracker
+	if *formatFlag == state.FormatParquet && *readersFlag > 1 {
+		if ranges, err = local.SplitRows(random, *readersFlag); err != nil {
+			log.Default.Fatal("failed to split input file into ranges of rows", zap.String("input", inputName), zap.Error(err))
+		}
+	} else if *readersFlag > 1 || *checkpointFlag != "" {
+		src, err := openSource()
+		if err != nil {
+			log.Default.Fatal("failed to open input file", zap.String("input", inputName), zap.Error(err))
+		}
+		var headerEnd int64
+		if *formatFlag != state.FormatJSONLines {
+			// Ranges are read from their offset, so the header is read separately.
+			o.columns, headerEnd, err = process.Header(src, src.Size, source)
+			if err != nil {
+				log.Default.Fatal("failed to read CSV header", zap.String("input", inputName), zap.Error(err))
+			}
+		}
+		var cr []checkpoint.Range
+		cr, err = local.Split(src, *readersFlag, headerEnd, source, len(o.columns), *checkpointFlag)
+		if err != nil {
+			log.Default.Fatal("failed to split input file into ranges", zap.String("input", inputName), zap.Error(err))
+		}
+		progress = checkpoint.NewTracker(src.Version, cr)
+		ranges = local.Remaining(src, cr)
+		if len(ranges) == 0 {
+			if len(cr) > 0 {
+				log.Default.Info("import already complete", zap.String("input", inputName), zap.String("checkpoint", *checkpointFlag))
+				return
+			}
+			// Empty files have no ranges, but still need to be read.
+			ranges = []local.Range{{Start: 0, End: 0, Open: src.Opener(0, 0)}}
+			progress = nil
+		}
+	}
+	importLocal(o, ranges, progress, *checkpointFlag)
+}
+
+func hasRule(rules []route.Rule, tableName string) bool {
+	for _, r := range rules {
+		if r.Table == tableName {
+			return true
+		}
 	}
-	importLocal(input, inputName, numericFields, booleanFields, delimiter(*delimiterFlag), *tableRegionFlag, *tableNameFlag, *concurrencyFlag)
+	return false
 }
 
 func setLambdaFunctionS3Location(template map[string]interface{}, zipLocation string) {
 	changeKey(template, zipLocation, "Resources", "PreflightLambdaFunction", "Properties", "Code", "S3Key")
 	changeKey(template, zipLocation, "Resources", "ImportLambdaFunction", "Properties", "Code", "S3Key")
+	changeKey(template, zipLocation, "Resources", "SummaryLambdaFunction", "Properties", "Code", "S3Key")
 	return
 }
 
@@ -321,6 +717,8 @@ func importRemote(stepFnRegion string, input state.Input) {
 		zap.String("sourceBucket", input.Source.Bucket),
 		zap.String("sourceKey", input.Source.Key),
 		zap.String("delimiter", input.Source.Delimiter),
+		zap.String("format", input.Source.Format),
+		zap.String("mode", input.Configuration.Mode),
 		zap.String("tableRegion", input.Target.Region),
 		zap.String("tableName", input.Target.TableName))
 
@@ -395,21 +793,18 @@ waitForOutput:
 		}
 	}
 
-	var output []sfnResponse
+	var output state.Summary
 	err = json.Unmarshal([]byte(outputPayload), &output)
 	if err != nil {
 		logger.Fatal("failed to unmarshal output", zap.String("output", outputPayload), zap.Error(err))
 	}
-	var lines int64
-	for _, op := range output {
-		lines += op.ProcessedCount
-	}
-	logger.Info("complete", zap.Int64("lines", lines))
-}
-
-type sfnResponse struct {
-	ProcessedCount int64 `json:"processedCount"`
-	DurationMS     int64 `json:"durationMs"`
+	logger.Info("complete",
+		zap.Int64("lines", output.ProcessedCount),
+		zap.Int64("skipped", output.SkippedCount),
+		zap.Int64("rejected", output.RejectedCount),
+		zap.Int("maxItemSize", output.MaxItemSize),
+		zap.Int("p99ItemSize", output.P99ItemSize),
+		zap.Float64("meanItemSize", output.MeanItemSize))
 }
 
 func s3Get(region, bucket, key string) (io.ReadCloser, error) {
@@ -427,85 +822,790 @@ func s3Get(region, bucket, key string) (io.ReadCloser, error) {
 	return goo.Body, err
 }
 
-func importLocal(input func() (io.ReadCloser, error), inputName string, numericFields, booleanFields []string, delimiter rune, tableRegion, tableName string, concurrency int) {
-	logger := log.Default.With(zap.String("input", inputName),
-		zap.String("tableRegion", tableRegion),
+func createTable(region, tableName string, schema table.Schema) {
+	logger := log.Default.With(zap.String("tableRegion", region),
 		zap.String("tableName", tableName))
+	logger.Info("creating table if it doesn't exist")
+	created, err := table.Create(region, tableName, schema)
+	if err != nil {
+		logger.Fatal("failed to create table", zap.Error(err))
+	}
+	if !created {
+		logger.Info("table already exists")
+		return
+	}
+	logger.Info("table created")
+}
 
-	logger.Info("starting local import")
+// capacityRestorer restores the capacity of every table that has been raised when the import
+// completes, fails or is interrupted.
+type capacityRestorer struct {
+	m        sync.Mutex
+	restores []func()
+}
 
-	start := time.Now()
-	var duration time.Duration
+// newCapacityRestorer creates a capacityRestorer, and registers a single hook for failed imports
+// and a single signal handler, which restore the capacity of every table before the exit.
+func newCapacityRestorer() *capacityRestorer {
+	cr := &capacityRestorer{}
+	log.Default = log.Default.WithOptions(zap.Hooks(func(e zapcore.Entry) error {
+		if e.Level == zapcore.FatalLevel {
+			cr.restore()
+		}
+		return nil
+	}))
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cr.restore()
+		os.Exit(1)
+	}()
+	return cr
+}
 
-	// Create dependencies.
-	f, err := input()
-	if err != nil {
-		logger.Fatal("failed to open input file", zap.Error(err))
+func (cr *capacityRestorer) add(restore func()) {
+	cr.m.Lock()
+	defer cr.m.Unlock()
+	cr.restores = append(cr.restores, restore)
+}
+
+func (cr *capacityRestorer) restore() {
+	cr.m.Lock()
+	restores := cr.restores
+	cr.m.Unlock()
+	for _, restore := range restores {
+		restore()
 	}
-	defer f.Close()
+}
 
-	csvr := csv.NewReader(f)
-	csvr.Comma = delimiter
-	conf := csvtodynamo.NewConfiguration()
-	conf.AddNumberKeys(numericFields...)
-	conf.AddBoolKeys(booleanFields...)
-	reader, err := csvtodynamo.NewConverter(csvr, conf)
+// raiseCapacity raises the write capacity of the table, returning a function that restores it.
+// If the capacity can't be raised, it's restored before the import exits.
+func raiseCapacity(region, tableName string, writeCapacity int64) (restore func()) {
+	logger := log.Default.With(zap.String("tableRegion", region),
+		zap.String("tableName", tableName),
+		zap.Int64("writeCapacity", writeCapacity))
+	r, err := capacity.New(region)
 	if err != nil {
-		logger.Fatal("failed to create CSV reader", zap.Error(err))
+		logger.Fatal("failed to create capacity raiser", zap.Error(err))
 	}
+	logger.Info("raising write capacity")
+	original, err := r.Raise(tableName, writeCapacity)
+	if errors.Is(err, capacity.ErrOnDemand) {
+		logger.Warn("the table uses on-demand capacity, so capacity won't be raised")
+		return func() {}
+	}
+	var once sync.Once
+	restore = func() {
+		once.Do(func() {
+			logger.Info("restoring capacity")
+			if err := r.Restore(original); err != nil {
+				logger.Error("failed to restore capacity", zap.Error(err))
+				return
+			}
+			logger.Info("capacity restored")
+		})
+	}
+	if err != nil {
+		restore()
+		logger.Fatal("failed to raise capacity", zap.Error(err))
+	}
+	logger.Info("write capacity raised")
+	return
+}
 
-	batchWriter, err := batchwriter.New(tableRegion, tableName)
+func s3Size(region, bucket, key string) (int64, error) {
+	o, err := s3reader.Open(region, bucket, key)
+	return o.Size, err
+}
+
+func fileSize(name string) (int64, error) {
+	fi, err := os.Stat(name)
 	if err != nil {
-		logger.Fatal("failed to create batch writer", zap.Error(err))
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+type columnTyper interface {
+	Columns() []string
+	AttributeType(column string) string
+}
+
+// validateColumns checks that the columns of the reader match the key schema of the table, if the
+// reader has columns.
+func validateColumns(logger *zap.Logger, schema table.Schema, mode string, reader dedupe.ItemReader) (err error) {
+	ct, ok := reader.(columnTyper)
+	if !ok {
+		return
+	}
+	if mode == batchwriter.ModeDelete {
+		// Only the table keys are used to delete items.
+		schema.Indexes = nil
+	}
+	warnings, err := table.Validate(schema, ct.Columns(), ct.AttributeType)
+	for _, w := range warnings {
+		logger.Warn(w)
+	}
+	return
+}
+
+// newRouteWriter checks that the columns routed to each table match the table's key schema, and
+// creates a writer that routes records to the tables.
+func newRouteWriter(logger *zap.Logger, tableRegion string, rules []route.Rule, reader dedupe.ItemReader) (w batchwriter.Writer, err error) {
+	schemas := map[string]table.Schema{}
+	keyNames := map[string][]string{}
+	for _, tableName := range route.Tables(rules) {
+		if schemas[tableName], err = table.Describe(tableRegion, tableName); err != nil {
+			return
+		}
+		keyNames[tableName] = schemas[tableName].KeyNames()
+	}
+	if ct, ok := reader.(columnTyper); ok {
+		hasColumn := map[string]bool{}
+		for _, c := range ct.Columns() {
+			hasColumn[c] = true
+		}
+		for _, r := range rules {
+			columns := r.Columns
+			if len(columns) == 0 {
+				columns = ct.Columns()
+			}
+			used := append([]string{}, columns...)
+			for _, c := range r.Conditions {
+				used = append(used, c.Column)
+			}
+			for _, c := range used {
+				if !hasColumn[c] {
+					return nil, fmt.Errorf("the route to table %q uses the column %q, which isn't in the file", r.Table, c)
+				}
+			}
+			var warnings []string
+			warnings, err = table.Validate(schemas[r.Table], columns, ct.AttributeType)
+			for _, warning := range warnings {
+				logger.Warn(warning, zap.String("routeTable", r.Table))
+			}
+			if err != nil {
+				return nil, fmt.Errorf("the columns routed to table %q don't match the table: %w", r.Table, err)
+			}
+		}
+	}
+	return route.NewWriter(tableRegion, rules, keyNames)
+}
+
+// options are the settings of an import, which are built once from the flags and shared by each
+// kind of import.
+type options struct {
+	// inputName is the name of the input file used in logs.
+	inputName string
+	// input opens the input file from the start.
+	input     func() (io.ReadCloser, error)
+	inputSize func() (int64, error)
+	// random is the input of Parquet and XLSX files, which are read using random access.
+	random          local.Source
+	cs              charset.Charset
+	format          string
+	numericFields   []string
+	booleanFields   []string
+	columns         []string
+	dialect         textformat.Dialect
+	layout          textformat.Layout
+	sheet           string
+	targets         []local.Target
+	mode            string
+	concurrency     int
+	itemConcurrency int
+	updateOptions   batchwriter.UpdateOptions
+	onDuplicateKey  dedupe.Policy
+	shuffleWindow   int
+	rules           []route.Rule
+}
+
+// configuration of the conversion of CSV and fixed-width records.
+func (o options) configuration() *csvtodynamo.Configuration {
+	conf := csvtodynamo.NewConfiguration().AddNumberKeys(o.numericFields...).AddBoolKeys(o.booleanFields...)
+	conf.EmptyAsNull = o.mode == batchwriter.ModeUpdate && o.updateOptions.RemoveEmpty
+	conf.Columns = o.columns
+	return conf
+}
+
+// newReader creates a reader of the records streamed from f, using the dialect and configuration of
+// the range that's read. Parquet and XLSX files are read from the random access input instead, and
+// only the rows of a Parquet file from (inclusive) to (exclusive) are read. If to is negative, all of
+// the rows from the start are read.
+func (o options) newReader(f io.Reader, from, to int64, dialect textformat.Dialect, conf *csvtodynamo.Configuration) (reader dedupe.ItemReader, err error) {
+	if o.format == state.FormatJSONLines {
+		return jsonltodynamo.NewConverter(f), nil
+	}
+	if o.format == state.FormatParquet || o.format == state.FormatXLSX {
+		reader, _, err = o.newTableReader(from, to, conf.EmptyAsNull)
+		return
+	}
+	if dialect.SkipLines > 0 {
+		lr := linereader.New(f, 0, 0, nil)
+		if err = lr.Skip(dialect.SkipLines); err != nil {
+			return
+		}
+		f = lr
+	}
+	if o.format == state.FormatFixedWidth {
+		return csvtodynamo.NewConverter(fixedwidth.NewReader(f, o.layout), conf)
+	}
+	csvr := dialect.NewReader(f)
+	csvr.FieldsPerRecord = len(conf.Columns)
+	return csvtodynamo.NewConverter(csvr, conf)
+}
+
+// newTableReader creates a reader of the rows [from, to) of a Parquet file, or of the rows of an XLSX
+// sheet, returning the number of rows that the file contains. Rows before the header of the sheet are
+// skipped like lines.
+func (o options) newTableReader(from, to int64, emptyAsNull bool) (reader dedupe.ItemReader, rows int64, err error) {
+	if o.format == state.FormatXLSX {
+		var wb *xlsxtodynamo.Workbook
+		if wb, err = xlsxtodynamo.Open(o.random, o.random.Size); err != nil {
+			return
+		}
+		var c *xlsxtodynamo.Converter
+		if c, err = wb.NewConverter(o.sheet, o.dialect.SkipLines); err != nil {
+			return
+		}
+		c.NullAttributes = emptyAsNull
+		return c, c.Rows(), nil
+	}
+	var pf *parquettodynamo.File
+	if pf, err = parquettodynamo.Open(o.random, o.random.Size); err != nil {
+		return
+	}
+	var c *parquettodynamo.Converter
+	if c, err = pf.NewConverter(from, to); err != nil {
+		return
+	}
+	c.NullAttributes = emptyAsNull
+	return c, pf.Rows(), nil
+}
+
+// saveCheckpoints saves the progress to the checkpoint file every interval until stop is closed.
+func saveCheckpoints(logger *zap.Logger, progress *checkpoint.Tracker, name string, interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := checkpoint.Save(name, progress.Checkpoint()); err != nil {
+				logger.Warn("failed to save checkpoint", zap.String("checkpoint", name), zap.Error(err))
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func importLocal(o options, ranges []local.Range, progress *checkpoint.Tracker, checkpointFile string) {
+	logger := log.Default.With(zap.String("input", o.inputName),
+		zap.String("mode", o.mode))
+	if len(o.targets) == 1 {
+		logger = logger.With(zap.String("tableRegion", o.targets[0].Region),
+			zap.String("tableName", o.targets[0].TableName))
 	}
 
-	var batchCount int64 = 1
-	var recordCount int64
+	logger.Info("starting local import", zap.Int("targets", len(o.targets)))
+
+	start := time.Now()
+	var duration time.Duration
+
+	// Create dependencies.
+	conf := o.configuration()
+	// Unless the header has already been read, only the first range contains the CSV header, so its
+	// columns are used by the other ranges.
+	itemReaders := make([]dedupe.ItemReader, len(ranges))
+	lineReaders := make([]*linereader.LineReader, len(ranges))
+	for i, r := range ranges {
+		rc, err := r.Open()
+		if err != nil {
+			logger.Fatal("failed to open input file", zap.Int64("rangeStart", r.Start), zap.Error(err))
+		}
+		defer rc.Close()
+		f := o.cs.NewReader(rc)
+		if progress != nil {
+			// The line reader only passes one line at a time to the converter, so its offset is
+			// the end of the last record read.
+			lineReaders[i] = linereader.New(rc, 0, r.Start, nil).WithCharset(o.cs)
+			if o.format == state.FormatCSV {
+				// CSV records may contain quoted new lines, so the offset is only moved to the end
+				// of whole records, as in the preflight.
+				lineReaders[i] = linereader.NewCSV(rc, 0, r.Start, nil).WithCharset(o.cs).WithDialect(o.dialect)
+			}
+			f = lineReaders[i]
+		}
+		// Only the range at the start of the file contains the lines to skip.
+		rangeDialect := o.dialect
+		if r.Start > 0 {
+			rangeDialect.SkipLines = 0
+		}
+		rangeConf := conf
+		if ct, ok := itemReaders[0].(columnTyper); ok && i > 0 {
+			c := *conf
+			c.Columns = ct.Columns()
+			rangeConf = &c
+		}
+		itemReaders[i], err = o.newReader(f, r.Start, r.End, rangeDialect, rangeConf)
+		if err != nil {
+			logger.Fatal("failed to create CSV reader", zap.Int64("rangeStart", r.Start), zap.Error(err))
+		}
+	}
+	itemReader := itemReaders[0]
+	var schema table.Schema
+	// Each target has a queue of 128 batches per target, so that a target can fall behind the
+	// others without holding up the readers.
+	queueSize := 128 * len(o.targets)
+	pools := make([]*local.Pool, len(o.targets))
+	for i, t := range o.targets {
+		targetLogger := logger.With(zap.String("tableRegion", t.Region), zap.String("tableName", t.TableName))
+		targetSchema, err := table.Describe(t.Region, t.TableName)
+		if err != nil {
+			targetLogger.Fatal("failed to describe table", zap.Error(err))
+		}
+		if i == 0 {
+			schema = targetSchema
+		} else if strings.Join(schema.KeyNames(), ",") != strings.Join(targetSchema.KeyNames(), ",") {
+			// Duplicate keys are removed from batches before they're sent to the targets.
+			targetLogger.Fatal("all tables must have the same keys", zap.Strings("expected", schema.KeyNames()), zap.Strings("actual", targetSchema.KeyNames()))
+		}
+		var batchWriter batchwriter.Writer
+		if len(o.rules) > 0 {
+			batchWriter, err = newRouteWriter(targetLogger, t.Region, o.rules, itemReader)
+			if err != nil {
+				targetLogger.Fatal("failed to create route writer", zap.Error(err))
+			}
+		} else {
+			if err = validateColumns(targetLogger, targetSchema, o.mode, itemReader); err != nil {
+				targetLogger.Fatal("the file doesn't match the table", zap.Error(err))
+			}
+			batchWriter, err = batchwriter.NewForMode(o.mode, t.Region, t.TableName, targetSchema.KeyNames(), o.itemConcurrency, o.updateOptions)
+			if err != nil {
+				targetLogger.Fatal("failed to create batch writer", zap.Error(err))
+			}
+		}
+		pools[i] = local.NewPool(t, targetLogger, batchWriter, queueSize)
+	}
+	sizeFilters := make([]*csvtodynamo.SizeFilter, len(ranges))
+	readers := make([]*dedupe.Reader, len(ranges))
+	for i, r := range ranges {
+		rangeLogger := logger
+		if len(ranges) > 1 {
+			// Line numbers are relative to the start of the range.
+			rangeLogger = logger.With(zap.Int64("rangeStart", r.Start))
+		}
+		sizeFilters[i] = csvtodynamo.NewSizeFilter(itemReaders[i], func(line int64, size int) {
+			rangeLogger.Warn("rejected item larger than the maximum item size", zap.Int64("line", line), zap.Int("size", size))
+		})
+		var shuffled dedupe.ItemReader = sizeFilters[i]
+		if o.shuffleWindow > 0 {
+			shuffled = shuffle.New(sizeFilters[i], schema.Keys[0].Name, o.shuffleWindow)
+		}
+		readers[i] = dedupe.New(shuffled, schema.KeyNames(), o.onDuplicateKey)
+		if o.format != state.FormatParquet {
+			readers[i].Offset = r.Start
+		}
+	}
 
 	// Start up workers.
-	batches := make(chan []map[string]*dynamodb.AttributeValue, 128) // 128 * 400KB max size allows the use of 50MB of RAM.
-	var wg sync.WaitGroup
-	wg.Add(concurrency)
-	for i := 0; i < concurrency; i++ {
-		go func(workerIndex int) {
-			defer wg.Done()
-			for batch := range batches {
-				err := batchWriter.Write(batch)
-				if err != nil {
-					logger.Error("error executing batch write", zap.Int("workerIndex", workerIndex), zap.Error(err))
-					return
+	for _, p := range pools {
+		p.Start(o.concurrency, start)
+	}
+	stopCheckpoints := make(chan struct{})
+	if progress != nil && checkpointFile != "" {
+		go saveCheckpoints(logger, progress, checkpointFile, 10*time.Second, stopCheckpoints)
+	}
+
+	// Push data from each range into the job queues of the targets that haven't failed.
+	var batchCount, recordCount int64
+	var readersWG sync.WaitGroup
+	readersWG.Add(len(readers))
+	for i, reader := range readers {
+		go func(r local.Range, reader *dedupe.Reader, lr *linereader.LineReader) {
+			defer readersWG.Done()
+			for {
+				batch, _, err := reader.ReadBatch()
+				if err != nil && err != io.EOF {
+					logger.Fatal("failed to read batch from input",
+						zap.Int64("rangeStart", r.Start),
+						zap.Int64("batchCount", atomic.LoadInt64(&batchCount)),
+						zap.Error(err))
 				}
-				recordCount := atomic.AddInt64(&recordCount, int64(len(batch)))
-				if batchCount := atomic.AddInt64(&batchCount, 1); batchCount%100 == 0 {
-					duration = time.Since(start)
-					logger.Info("progress", zap.Int("workerIndex", workerIndex), zap.Int64("records", recordCount), zap.Int("rps", int(float64(recordCount)/duration.Seconds())))
+				if len(batch) > 0 {
+					atomic.AddInt64(&batchCount, 1)
+					atomic.AddInt64(&recordCount, int64(len(batch)))
+					job := local.Job{Batch: batch}
+					if progress != nil {
+						job.Done = progress.Add(r.Checkpoint, lr.Offset, len(pools))
+					}
+					if !local.SendAll(pools, job) {
+						return
+					}
+				}
+				if err == io.EOF {
+					return
 				}
 			}
-		}(i)
+		}(ranges[i], reader, lineReaders[i])
+	}
+	readersWG.Wait()
+	var stats csvtodynamo.SizeStats
+	for _, sf := range sizeFilters {
+		stats.Merge(sf.Stats())
+	}
+
+	// Wait for completion.
+	var completed, failed []string
+	errs := make([]error, len(pools))
+	for i, p := range pools {
+		errs[i] = p.Wait()
+	}
+	close(stopCheckpoints)
+	if progress != nil && checkpointFile != "" {
+		if err := checkpoint.Save(checkpointFile, progress.Checkpoint()); err != nil {
+			logger.Error("failed to save checkpoint", zap.String("checkpoint", checkpointFile), zap.Error(err))
+		}
+	}
+	for i, p := range pools {
+		targetLogger := p.Logger.With(zap.Int64("records", p.Records()))
+		if cpw, ok := p.Writer.(*batchwriter.ConditionalPutWriter); ok {
+			targetLogger = targetLogger.With(zap.Int64("inserted", cpw.Inserted()), zap.Int64("skipped", cpw.Skipped()))
+		}
+		if errs[i] != nil {
+			failed = append(failed, p.String())
+			targetLogger.Error("target failed", zap.Error(errs[i]))
+			continue
+		}
+		completed = append(completed, p.String())
+		if len(pools) > 1 {
+			targetLogger.Info("target complete")
+		}
+	}
+	duration = time.Since(start)
+	logger = logger.With(zap.Int64("records", recordCount),
+		zap.Int("rps", int(float64(recordCount)/duration.Seconds())),
+		zap.Duration("duration", duration),
+		zap.Int64("rejected", stats.Rejected),
+		zap.Int("maxItemSize", stats.Max),
+		zap.Int("p99ItemSize", stats.Percentile(99)),
+		zap.Float64("meanItemSize", stats.Mean()))
+	if len(pools) > 1 {
+		logger = logger.With(zap.Strings("completed", completed), zap.Strings("failed", failed))
+	} else if cpw, ok := pools[0].Writer.(*batchwriter.ConditionalPutWriter); ok {
+		logger = logger.With(zap.Int64("inserted", cpw.Inserted()), zap.Int64("skipped", cpw.Skipped()))
+	}
+	if len(failed) > 0 {
+		logger.Fatal("import failed")
 	}
+	logger.Info("complete")
+}
+
+func importLocalTransaction(o options, token string, rollback bool) {
+	t := o.targets[0]
+	logger := log.Default.With(zap.String("input", o.inputName),
+		zap.String("tableRegion", t.Region),
+		zap.String("tableName", t.TableName),
+		zap.String("mode", batchwriter.ModeTransaction),
+		zap.String("transactionToken", token))
+
+	logger.Info("starting local transactional import")
+
+	start := time.Now()
 
-	// Push data into the job queue.
+	// Create dependencies.
+	f, err := o.input()
+	if err != nil {
+		logger.Fatal("failed to open input file", zap.Error(err))
+	}
+	defer f.Close()
+	schema, err := table.Describe(t.Region, t.TableName)
+	if err != nil {
+		logger.Fatal("failed to describe table", zap.Error(err))
+	}
+	itemReader, err := o.newReader(o.cs.NewReader(f), 0, -1, o.dialect, o.configuration())
+	if err != nil {
+		logger.Fatal("failed to create CSV reader", zap.Error(err))
+	}
+	if err = validateColumns(logger, schema, batchwriter.ModeTransaction, itemReader); err != nil {
+		logger.Fatal("the file doesn't match the table", zap.Error(err))
+	}
+	sizeFilter := csvtodynamo.NewSizeFilter(itemReader, func(line int64, size int) {
+		logger.Warn("rejected item larger than the maximum item size", zap.Int64("line", line), zap.Int("size", size))
+	})
+	reader := dedupe.New(sizeFilter, schema.KeyNames(), o.onDuplicateKey)
+	tw, err := batchwriter.NewTransactionWriter(t.Region, t.TableName, schema.KeyNames(), token, rollback)
+	if err != nil {
+		logger.Fatal("failed to create transaction writer", zap.Error(err))
+	}
+
+	// Read the whole file before writing anything, so that invalid data doesn't result in a partial import.
+	var batches [][]map[string]*dynamodb.AttributeValue
+	var recordCount int
 	for {
 		batch, _, err := reader.ReadBatch()
 		if err != nil && err != io.EOF {
 			logger.Fatal("failed to read batch from input",
-				zap.Int64("batchCount", batchCount),
+				zap.Int("batchCount", len(batches)),
 				zap.Error(err))
 		}
 		if len(batch) > 0 {
-			batches <- batch
+			batches = append(batches, batch)
+			recordCount += len(batch)
 		}
 		if err == io.EOF {
 			break
 		}
 	}
-	close(batches)
+	logger.Info("read input", zap.Int("records", recordCount), zap.Int("transactions", len(batches)))
 
-	// Wait for completion.
-	wg.Wait()
-	duration = time.Since(start)
+	// Write the transactions in order.
+	for _, batch := range batches {
+		err = tw.Write(batch)
+		if err == nil {
+			continue
+		}
+		chunks, items := tw.Committed()
+		logger = logger.With(zap.Int("committedTransactions", chunks),
+			zap.Int("totalTransactions", len(batches)),
+			zap.Int("committedRecords", items),
+			zap.Int("totalRecords", recordCount))
+		if !rollback {
+			logger.Fatal("transaction failed, committed records have not been rolled back", zap.Error(err))
+		}
+		logger.Error("transaction failed, rolling back committed records", zap.Error(err))
+		if rollbackErr := tw.Rollback(); rollbackErr != nil {
+			logger.Fatal("rollback failed", zap.Error(rollbackErr))
+		}
+		logger.Fatal("rollback complete")
+	}
+	chunks, items := tw.Committed()
 	logger.Info("complete",
-		zap.Int64("records", recordCount),
-		zap.Int("rps", int(float64(recordCount)/duration.Seconds())),
-		zap.Duration("duration", duration))
+		zap.Int("transactions", chunks),
+		zap.Int("records", items),
+		zap.Duration("duration", time.Since(start)),
+		zap.Int64("rejected", sizeFilter.Stats().Rejected),
+		zap.Int("maxItemSize", sizeFilter.Stats().Max),
+		zap.Int("p99ItemSize", sizeFilter.Stats().Percentile(99)),
+		zap.Float64("meanItemSize", sizeFilter.Stats().Mean()))
+}
+
+func dryRun(o options, printItems int) {
+	t := o.targets[0]
+	logger := log.Default.With(zap.String("input", o.inputName),
+		zap.String("tableRegion", t.Region),
+		zap.String("tableName", t.TableName),
+		zap.String("mode", o.mode),
+		zap.Bool("dryRun", true))
+
+	logger.Info("starting dry run")
+
+	start := time.Now()
+
+	// Create dependencies.
+	f, err := o.input()
+	if err != nil {
+		logger.Fatal("failed to open input file", zap.Error(err))
+	}
+	defer f.Close()
+	conf := o.configuration()
+	conf.ValidateTypes = true
+	itemReader, err := o.newReader(o.cs.NewReader(f), 0, -1, o.dialect, conf)
+	if err != nil {
+		logger.Fatal("failed to create CSV reader", zap.Error(err))
+	}
+	schema, err := table.Describe(t.Region, t.TableName)
+	if err != nil {
+		logger.Warn("failed to describe table, skipping key validation", zap.Error(err))
+	} else if err = validateColumns(logger, schema, o.mode, itemReader); err != nil {
+		logger.Fatal("the file doesn't match the table", zap.Error(err))
+	}
+	sizeFilter := csvtodynamo.NewSizeFilter(itemReader, func(line int64, size int) {
+		logger.Warn("rejected item larger than the maximum item size", zap.Int64("line", line), zap.Int("size", size))
+	})
+
+	// Read the whole file, carrying on past invalid records to report all of them.
+	var invalid int64
+	var printed int
+	for {
+		item, err := sizeFilter.Read()
+		if err == io.EOF {
+			break
+		}
+		if isInvalidRecord(err) {
+			invalid++
+			logger.Warn("invalid record", zap.Error(err))
+			continue
+		}
+		if err != nil {
+			logger.Fatal("failed to read from input", zap.Int64("line", sizeFilter.Line()), zap.Error(err))
+		}
+		if printed < printItems {
+			printed++
+			if err = json.NewEncoder(os.Stdout).Encode(dynamoJSON(item)); err != nil {
+				logger.Fatal("failed to print item", zap.Error(err))
+			}
+		}
+	}
+
+	stats := sizeFilter.Stats()
+	writeUnits := stats.WriteUnits
+	if o.mode == batchwriter.ModeTransaction {
+		// Transactional writes consume twice the capacity.
+		writeUnits *= 2
+	}
+	logger.Info("dry run complete",
+		zap.Int64("records", stats.Count),
+		zap.Int64("invalid", invalid),
+		zap.Int64("rejected", stats.Rejected),
+		zap.Int64("writeUnits", writeUnits),
+		zap.Int("maxItemSize", stats.Max),
+		zap.Int("p99ItemSize", stats.Percentile(99)),
+		zap.Float64("meanItemSize", stats.Mean()),
+		zap.Duration("duration", time.Since(start)))
+}
+
+// stepFunctionMaxConcurrency is the number of Lambda functions that the ddbimport Step Function runs in parallel.
+const stepFunctionMaxConcurrency = 50
+
+// estimateWorkers returns the number of write requests that are executed in parallel by a local
+// import, and the number of records written by each request.
+func estimateWorkers(mode string, concurrency, itemConcurrency int) (workers, recordsPerRequest int) {
+	switch mode {
+	case batchwriter.ModePutIfNotExists, batchwriter.ModeUpdate:
+		// Each record is written by a single item request.
+		return concurrency * itemConcurrency, 1
+	case batchwriter.ModeTransaction:
+		// Transactions are written one after the other.
+		return 1, 25
+	}
+	return concurrency, 25
+}
+
+func estimateImport(o options, in estimate.Input, sampleSize int, printJSON bool) {
+	t := o.targets[0]
+	logger := log.Default.With(zap.String("input", o.inputName),
+		zap.String("tableRegion", t.Region),
+		zap.String("tableName", t.TableName),
+		zap.String("mode", o.mode))
+
+	logger.Info("starting estimate", zap.Int("sampleSize", sampleSize))
+
+	var err error
+	in.SourceBytes, err = o.inputSize()
+	if err != nil {
+		logger.Fatal("failed to get input file size", zap.Error(err))
+	}
+	f, err := o.input()
+	if err != nil {
+		logger.Fatal("failed to open input file", zap.Error(err))
+	}
+	defer f.Close()
+	// The line reader only passes one line at a time to the converter, so its offset is the number of
+	// bytes of the source that have been sampled.
+	lr := linereader.New(f, 0, 0, nil).WithCharset(o.cs)
+	conf := o.configuration()
+	var itemReader dedupe.ItemReader
+	var rows int64
+	if o.format == state.FormatParquet || o.format == state.FormatXLSX {
+		itemReader, rows, err = o.newTableReader(0, -1, conf.EmptyAsNull)
+	} else {
+		itemReader, err = o.newReader(lr, 0, -1, o.dialect, conf)
+	}
+	if err != nil {
+		logger.Fatal("failed to create CSV reader", zap.Error(err))
+	}
+	sizeFilter := csvtodynamo.NewSizeFilter(itemReader, nil)
+	for i := 0; i < sampleSize; i++ {
+		_, err = sizeFilter.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			logger.Fatal("failed to read from input", zap.Int64("line", sizeFilter.Line()), zap.Error(err))
+		}
+	}
+	stats := sizeFilter.Stats()
+	in.SampleBytes = lr.Offset
+	if rows > 0 {
+		// Parquet and XLSX files are compressed, so the sample is the proportion of the rows read.
+		in.SampleBytes = in.SourceBytes * stats.Count / rows
+	}
+	if err == io.EOF {
+		in.SampleBytes = in.SourceBytes
+	}
+	in.SampleRecords = stats.Count
+	in.SampleWriteUnits = stats.WriteUnits
+	in.SampleItemBytes = stats.Total
+
+	in.WriteUnitMultiplier = 1
+	schema, err := table.Describe(t.Region, t.TableName)
+	if err != nil {
+		logger.Warn("failed to describe table, assuming the table has no indexes", zap.Error(err))
+	} else {
+		// Each index is written to as well as the table.
+		in.WriteUnitMultiplier += float64(len(schema.Indexes))
+		if in.ProvisionedWriteCapacity == 0 {
+			in.ProvisionedWriteCapacity = schema.WriteCapacityUnits
+		}
+	}
+	if o.mode == batchwriter.ModeTransaction {
+		// Transactional writes consume twice the capacity.
+		in.WriteUnitMultiplier *= 2
+	}
+
+	report := estimate.Estimate(in)
+	if printJSON {
+		if err = json.NewEncoder(os.Stdout).Encode(report); err != nil {
+			logger.Fatal("failed to print estimate", zap.Error(err))
+		}
+		return
+	}
+	fmt.Print(report.String())
+}
+
+// isInvalidRecord returns true if the error relates to a single record, so that reading can continue.
+func isInvalidRecord(err error) bool {
+	var parseError *csv.ParseError
+	var invalidValueError csvtodynamo.InvalidValueError
+	var syntaxError *json.SyntaxError
+	return errors.As(err, &parseError) || errors.As(err, &invalidValueError) || errors.As(err, &syntaxError)
+}
+
+// dynamoJSON converts the item to the DynamoDB JSON format, e.g. {"name": {"S": "value"}}.
+func dynamoJSON(item map[string]*dynamodb.AttributeValue) map[string]interface{} {
+	m := make(map[string]interface{}, len(item))
+	for k, v := range item {
+		m[k] = dynamoValueJSON(v)
+	}
+	return m
+}
+
+func dynamoValueJSON(v *dynamodb.AttributeValue) interface{} {
+	switch {
+	case v.S != nil:
+		return map[string]interface{}{"S": *v.S}
+	case v.N != nil:
+		return map[string]interface{}{"N": *v.N}
+	case v.B != nil:
+		return map[string]interface{}{"B": v.B}
+	case v.BOOL != nil:
+		return map[string]interface{}{"BOOL": *v.BOOL}
+	case v.NULL != nil:
+		return map[string]interface{}{"NULL": *v.NULL}
+	case v.SS != nil:
+		return map[string]interface{}{"SS": v.SS}
+	case v.NS != nil:
+		return map[string]interface{}{"NS": v.NS}
+	case v.BS != nil:
+		return map[string]interface{}{"BS": v.BS}
+	case v.L != nil:
+		l := make([]interface{}, len(v.L))
+		for i, e := range v.L {
+			l[i] = dynamoValueJSON(e)
+		}
+		return map[string]interface{}{"L": l}
+	}
+	return map[string]interface{}{"M": dynamoJSON(v.M)}
 }
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSetLambdaFunctionS3Location(t *testing.T) {
+	function := func(key string) map[string]interface{} {
+		return map[string]interface{}{
+			"Properties": map[string]interface{}{
+				"Code": map[string]interface{}{
+					"S3Bucket": "bucket",
+					"S3Key":    key,
+				},
+			},
+		}
+	}
+	template := map[string]interface{}{
+		"Resources": map[string]interface{}{
+			"PreflightLambdaFunction": function("serverless/preflight.zip"),
+			"ImportLambdaFunction":    function("serverless/import.zip"),
+			"SummaryLambdaFunction":   function("serverless/summary.zip"),
+		},
+	}
+	setLambdaFunctionS3Location(template, "ddbimport.zip")
+	expected := map[string]interface{}{
+		"Resources": map[string]interface{}{
+			"PreflightLambdaFunction": function("ddbimport.zip"),
+			"ImportLambdaFunction":    function("ddbimport.zip"),
+			"SummaryLambdaFunction":   function("ddbimport.zip"),
+		},
+	}
+	if diff := cmp.Diff(expected, template); diff != "" {
+		t.Error(diff)
+	}
+}
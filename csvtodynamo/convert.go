@@ -1,16 +1,24 @@
 package csvtodynamo
 
 import (
-	"encoding/csv"
+	"fmt"
+	"strings"
 
+	"github.com/a-h/ddbimport/keys"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
 
+// RecordReader reads records of fields, e.g. a csv.Reader.
+type RecordReader interface {
+	Read() (record []string, err error)
+}
+
 // Converter converts CSV to DynamoDB records.
 type Converter struct {
-	r           *csv.Reader
+	r           RecordReader
 	conf        *Configuration
 	columnNames []string
+	line        int64
 }
 
 type keyConverter func(s string) *dynamodb.AttributeValue
@@ -19,19 +27,38 @@ type keyConverter func(s string) *dynamodb.AttributeValue
 func NewConfiguration() *Configuration {
 	return &Configuration{
 		KeyToConverter: map[string]keyConverter{},
+		keyToType:      map[string]string{},
 	}
 }
 
 // Configuration for the Converter.
 type Configuration struct {
 	KeyToConverter map[string]keyConverter
+	keyToType      map[string]string
 	Columns        []string
+	// EmptyAsNull converts empty values to NULL attributes instead of omitting them.
+	EmptyAsNull bool
+	// ValidateTypes returns an InvalidValueError when a numeric or boolean value can't be converted.
+	ValidateTypes bool
+}
+
+// InvalidValueError is returned when a value can't be converted to the configured type.
+type InvalidValueError struct {
+	Line   int64
+	Column string
+	Value  string
+	Type   string
+}
+
+func (e InvalidValueError) Error() string {
+	return fmt.Sprintf("csvtodynamo: line %d: column %q value %q is not a valid %s", e.Line, e.Column, e.Value, e.Type)
 }
 
 // AddStringKeys add string keys to the configuration.
 func (conf *Configuration) AddStringKeys(s ...string) *Configuration {
 	for _, k := range s {
 		conf.KeyToConverter[k] = stringValue
+		conf.setType(k, dynamodb.ScalarAttributeTypeS)
 	}
 	return conf
 }
@@ -40,6 +67,7 @@ func (conf *Configuration) AddStringKeys(s ...string) *Configuration {
 func (conf *Configuration) AddNumberKeys(s ...string) *Configuration {
 	for _, k := range s {
 		conf.KeyToConverter[k] = numberValue
+		conf.setType(k, dynamodb.ScalarAttributeTypeN)
 	}
 	return conf
 }
@@ -48,10 +76,26 @@ func (conf *Configuration) AddNumberKeys(s ...string) *Configuration {
 func (conf *Configuration) AddBoolKeys(s ...string) *Configuration {
 	for _, k := range s {
 		conf.KeyToConverter[k] = boolValue
+		conf.setType(k, "BOOL")
 	}
 	return conf
 }
 
+func (conf *Configuration) setType(key, t string) {
+	if conf.keyToType == nil {
+		conf.keyToType = map[string]string{}
+	}
+	conf.keyToType[key] = t
+}
+
+// AttributeType returns the DynamoDB type that values of the key are converted to, e.g. S, N or BOOL.
+func (conf *Configuration) AttributeType(key string) string {
+	if t, ok := conf.keyToType[key]; ok {
+		return t
+	}
+	return dynamodb.ScalarAttributeTypeS
+}
+
 func (c *Converter) init() error {
 	if len(c.conf.Columns) > 0 {
 		c.columnNames = c.conf.Columns
@@ -61,12 +105,27 @@ func (c *Converter) init() error {
 	if err != nil {
 		return err
 	}
+	c.line++
 	if c.columnNames == nil {
-		c.columnNames = record
+		c.columnNames = Header(record)
 	}
 	return nil
 }
 
+// Header returns the column names of a CSV header record, without the UTF-8 byte order mark
+// that Windows tools add to the start of files, or a trailing carriage return.
+func Header(record []string) []string {
+	if len(record) == 0 {
+		return record
+	}
+	columns := append([]string{}, record...)
+	columns[0] = strings.TrimPrefix(columns[0], bom)
+	columns[len(columns)-1] = strings.TrimRight(columns[len(columns)-1], "\r")
+	return columns
+}
+
+const bom = "\ufeff"
+
 // ReadBatch reads 25 items from the CSV.
 // Only strings, numbers and boolean values are supported in CSV.
 func (c *Converter) ReadBatch() (items []map[string]*dynamodb.AttributeValue, read int, err error) {
@@ -87,17 +146,42 @@ func (c *Converter) Read() (items map[string]*dynamodb.AttributeValue, err error
 	if err != nil {
 		return
 	}
+	c.line++
 	items = make(map[string]*dynamodb.AttributeValue, len(record))
 	for i, column := range c.columnNames {
 		if len(record[i]) != 0 {
+			if c.conf.ValidateTypes && !c.isValid(column, record[i]) {
+				return nil, InvalidValueError{Line: c.line, Column: column, Value: record[i], Type: c.conf.AttributeType(column)}
+			}
 			items[column] = c.dynamoValue(column, record[i])
+			continue
+		}
+		if c.conf.EmptyAsNull {
+			items[column] = nullValue
 		}
 	}
 	return items, err
 }
 
-// NewConverter creates a new CSV to DynamoDB converter.
-func NewConverter(r *csv.Reader, conf *Configuration) (*Converter, error) {
+// Line returns the number of the last record read, including the header row if it was read
+// from the file. Records containing quoted newlines are counted as a single line.
+func (c *Converter) Line() int64 {
+	return c.line
+}
+
+// Columns returns the names of the columns.
+func (c *Converter) Columns() []string {
+	return c.columnNames
+}
+
+// AttributeType returns the DynamoDB type that values of the column are converted to.
+func (c *Converter) AttributeType(column string) string {
+	return c.conf.AttributeType(column)
+}
+
+// NewConverter creates a new CSV to DynamoDB converter. Records can be read from any RecordReader,
+// e.g. a fixed-width file, but unless the columns are configured, the first record is the header.
+func NewConverter(r RecordReader, conf *Configuration) (*Converter, error) {
 	if conf == nil {
 		conf = NewConfiguration()
 	}
@@ -116,6 +200,22 @@ func (c *Converter) dynamoValue(key, value string) *dynamodb.AttributeValue {
 	return stringValue(value)
 }
 
+func (c *Converter) isValid(key, value string) bool {
+	switch c.conf.AttributeType(key) {
+	case dynamodb.ScalarAttributeTypeN:
+		return isNumber(value)
+	case "BOOL":
+		_, ok := boolValues[value]
+		return ok
+	}
+	return true
+}
+
+// isNumber returns true if DynamoDB can store the value as a number.
+func isNumber(s string) bool {
+	return keys.ValidNumber(s) == nil
+}
+
 func stringValue(s string) *dynamodb.AttributeValue {
 	return (&dynamodb.AttributeValue{}).SetS(s)
 }
@@ -131,6 +231,7 @@ func boolValue(s string) *dynamodb.AttributeValue {
 	return falseValue
 }
 
+var nullValue = (&dynamodb.AttributeValue{}).SetNULL(true)
 var trueValue = (&dynamodb.AttributeValue{}).SetBOOL(true)
 var falseValue = (&dynamodb.AttributeValue{}).SetBOOL(false)
 
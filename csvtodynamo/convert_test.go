@@ -28,6 +28,20 @@ func TestConverter(t *testing.T) {
 			}, "\n"),
 			expectedError: csv.ErrFieldCount,
 		},
+		{
+			name: "byte order marks and Windows line endings are removed",
+			input: "\ufeff" + strings.Join([]string{
+				"a,b",
+				"1,2",
+				"",
+			}, "\r\n"),
+			expected: []map[string]*dynamodb.AttributeValue{
+				{
+					"a": &dynamodb.AttributeValue{S: aws.String("1")},
+					"b": &dynamodb.AttributeValue{S: aws.String("2")},
+				},
+			},
+		},
 		{
 			name: "numbers are not identified by default",
 			input: strings.Join([]string{
@@ -116,6 +130,21 @@ func TestConverter(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "empty values can be converted to null",
+			input: strings.Join([]string{
+				"a,b,c",
+				`the,,cork`,
+			}, "\n"),
+			config: &Configuration{KeyToConverter: map[string]keyConverter{}, EmptyAsNull: true},
+			expected: []map[string]*dynamodb.AttributeValue{
+				{
+					"a": &dynamodb.AttributeValue{S: aws.String("the")},
+					"b": &dynamodb.AttributeValue{NULL: aws.Bool(true)},
+					"c": &dynamodb.AttributeValue{S: aws.String("cork")},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -150,3 +179,64 @@ func TestConverter(t *testing.T) {
 	}
 
 }
+
+func TestAttributeType(t *testing.T) {
+	conf := NewConfiguration().AddNumberKeys("n").AddBoolKeys("b").AddStringKeys("s")
+	expected := map[string]string{
+		"n":       "N",
+		"b":       "BOOL",
+		"s":       "S",
+		"default": "S",
+	}
+	for key, e := range expected {
+		if actual := conf.AttributeType(key); actual != e {
+			t.Errorf("%s: expected %q, got %q", key, e, actual)
+		}
+	}
+}
+
+func TestValidateTypes(t *testing.T) {
+	var tests = []struct {
+		name          string
+		input         string
+		expectedError error
+	}{
+		{
+			name:  "valid values",
+			input: "n,b\n-1.5e10,TRUE",
+		},
+		{
+			name:          "invalid numbers",
+			input:         "n,b\none,TRUE",
+			expectedError: InvalidValueError{Line: 2, Column: "n", Value: "one", Type: "N"},
+		},
+		{
+			name:          "numbers with too many significant digits",
+			input:         "n,b\n" + strings.Repeat("1", 39) + ",TRUE",
+			expectedError: InvalidValueError{Line: 2, Column: "n", Value: strings.Repeat("1", 39), Type: "N"},
+		},
+		{
+			name:          "invalid booleans",
+			input:         "n,b\n1,yes",
+			expectedError: InvalidValueError{Line: 2, Column: "b", Value: "yes", Type: "BOOL"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			conf := NewConfiguration().AddNumberKeys("n").AddBoolKeys("b")
+			conf.ValidateTypes = true
+			c, err := NewConverter(csv.NewReader(strings.NewReader(tt.input)), conf)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			_, err = c.Read()
+			if tt.expectedError == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.expectedError != nil && !errors.Is(err, tt.expectedError) {
+				t.Errorf("expected error %v, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package csvtodynamo
+
+import (
+	"math"
+	"math/bits"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// MaxItemSize is the maximum size of a DynamoDB item in bytes.
+const MaxItemSize = 400 * 1024
+
+// ItemSize calculates the size of a DynamoDB item in bytes, using the rules in
+// https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/CapacityUnitCalculations.html
+func ItemSize(item map[string]*dynamodb.AttributeValue) (size int) {
+	for name, v := range item {
+		size += len(name) + valueSize(v)
+	}
+	return
+}
+
+func valueSize(v *dynamodb.AttributeValue) (size int) {
+	switch {
+	case v.S != nil:
+		return len(*v.S)
+	case v.N != nil:
+		return numberSize(*v.N)
+	case v.B != nil:
+		return len(v.B)
+	case v.BOOL != nil, v.NULL != nil:
+		return 1
+	case v.SS != nil:
+		for _, s := range v.SS {
+			size += len(*s)
+		}
+		return
+	case v.NS != nil:
+		for _, n := range v.NS {
+			size += numberSize(*n)
+		}
+		return
+	case v.BS != nil:
+		for _, b := range v.BS {
+			size += len(b)
+		}
+		return
+	case v.L != nil:
+		// Lists and maps have 3 bytes of overhead, plus 1 byte per element.
+		size = 3
+		for _, e := range v.L {
+			size += 1 + valueSize(e)
+		}
+		return
+	case v.M != nil:
+		size = 3
+		for name, e := range v.M {
+			size += 1 + len(name) + valueSize(e)
+		}
+		return
+	}
+	return
+}
+
+// WriteUnits returns the number of write capacity units consumed by writing an item of the given size.
+func WriteUnits(size int) int {
+	return (size + 1023) / 1024
+}
+
+// numberSize is 1 byte per 2 significant digits, plus 1 byte.
+func numberSize(n string) int {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, strings.SplitN(strings.ToLower(n), "e", 2)[0])
+	digits = strings.Trim(digits, "0")
+	return (len(digits)+1)/2 + 1
+}
+
+// SizeFilter reads items, rejecting items that are larger than MaxItemSize, and records size statistics.
+type SizeFilter struct {
+	r      itemReader
+	reject func(line int64, size int)
+	stats  SizeStats
+}
+
+type itemReader interface {
+	Read() (item map[string]*dynamodb.AttributeValue, err error)
+	Line() int64
+}
+
+// NewSizeFilter creates a SizeFilter which reads from r, calling reject with the line number and
+// size of each item that is too large to be written to DynamoDB.
+func NewSizeFilter(r itemReader, reject func(line int64, size int)) *SizeFilter {
+	return &SizeFilter{
+		r:      r,
+		reject: reject,
+	}
+}
+
+// Read the next item that isn't too large.
+func (sf *SizeFilter) Read() (item map[string]*dynamodb.AttributeValue, err error) {
+	for {
+		item, err = sf.r.Read()
+		if err != nil {
+			return
+		}
+		size := ItemSize(item)
+		if size <= MaxItemSize {
+			sf.stats.Add(size)
+			return
+		}
+		sf.stats.Rejected++
+		if sf.reject != nil {
+			sf.reject(sf.r.Line(), size)
+		}
+	}
+}
+
+// Line returns the line number of the last item read.
+func (sf *SizeFilter) Line() int64 {
+	return sf.r.Line()
+}
+
+// Stats returns the size statistics of the items that have been read.
+func (sf *SizeFilter) Stats() *SizeStats {
+	return &sf.stats
+}
+
+// sizeStatsBucketSize is the accuracy of the percentile calculations.
+const sizeStatsBucketSize = 64
+
+// SizeStats records statistics about item sizes.
+type SizeStats struct {
+	Count int64
+	Total int64
+	Max   int
+	// WriteUnits is the total number of write capacity units required to write the items.
+	WriteUnits int64
+	Rejected   int64
+	buckets    []int64
+}
+
+// Add an item size to the statistics.
+func (s *SizeStats) Add(size int) {
+	s.Count++
+	s.Total += int64(size)
+	s.WriteUnits += int64(WriteUnits(size))
+	if size > s.Max {
+		s.Max = size
+	}
+	if s.buckets == nil {
+		s.buckets = make([]int64, MaxItemSize/sizeStatsBucketSize+1)
+	}
+	b := size / sizeStatsBucketSize
+	if b >= len(s.buckets) {
+		b = len(s.buckets) - 1
+	}
+	s.buckets[b]++
+}
+
+// Merge the statistics of o into s.
+func (s *SizeStats) Merge(o *SizeStats) {
+	s.Count += o.Count
+	s.Total += o.Total
+	s.WriteUnits += o.WriteUnits
+	s.Rejected += o.Rejected
+	if o.Max > s.Max {
+		s.Max = o.Max
+	}
+	if o.buckets == nil {
+		return
+	}
+	if s.buckets == nil {
+		s.buckets = make([]int64, len(o.buckets))
+	}
+	for i, n := range o.buckets {
+		s.buckets[i] += n
+	}
+}
+
+// HistogramBuckets is the number of buckets returned by Histogram. Bucket i contains the items that
+// are smaller than 64<<i bytes, and not in an earlier bucket, so the last bucket contains items up to
+// MaxItemSize.
+const HistogramBuckets = 14
+
+// Histogram returns the number of items in each of the HistogramBuckets buckets of sizes, so that
+// the statistics can be sent to another process in a fixed amount of space, and merged using
+// AddHistogram.
+func (s *SizeStats) Histogram() []int64 {
+	h := make([]int64, HistogramBuckets)
+	for i, n := range s.buckets {
+		b := bits.Len(uint(i))
+		if b >= len(h) {
+			b = len(h) - 1
+		}
+		h[b] += n
+	}
+	return h
+}
+
+// AddHistogram adds the number of items in each bucket returned by Histogram. Items are counted at
+// the top of their bucket, so percentiles are accurate to within a factor of 2. The Count, Total and
+// Max are not changed.
+func (s *SizeStats) AddHistogram(h []int64) {
+	if len(h) == 0 {
+		return
+	}
+	if s.buckets == nil {
+		s.buckets = make([]int64, MaxItemSize/sizeStatsBucketSize+1)
+	}
+	for b, n := range h {
+		i := 1<<b - 1
+		if b < 0 || b >= HistogramBuckets || i >= len(s.buckets) {
+			i = len(s.buckets) - 1
+		}
+		s.buckets[i] += n
+	}
+}
+
+// Mean item size in bytes.
+func (s *SizeStats) Mean() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Total) / float64(s.Count)
+}
+
+// Percentile returns the item size in bytes that p percent of the items are smaller than or
+// equal to, to within 64 bytes.
+func (s *SizeStats) Percentile(p float64) int {
+	if s.Count == 0 {
+		return 0
+	}
+	target := int64(math.Ceil(float64(s.Count) * p / 100))
+	var seen int64
+	for i, n := range s.buckets {
+		seen += n
+		if seen >= target {
+			upper := (i + 1) * sizeStatsBucketSize
+			if upper > s.Max {
+				return s.Max
+			}
+			return upper
+		}
+	}
+	return s.Max
+}
@@ -0,0 +1,165 @@
+package csvtodynamo
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestItemSize(t *testing.T) {
+	var tests = []struct {
+		name     string
+		item     map[string]*dynamodb.AttributeValue
+		expected int
+	}{
+		{
+			name:     "strings are the length of the name and value",
+			item:     map[string]*dynamodb.AttributeValue{"name": {S: aws.String("Alice")}},
+			expected: 4 + 5,
+		},
+		{
+			name:     "numbers are 1 byte per 2 significant digits, plus 1",
+			item:     map[string]*dynamodb.AttributeValue{"n": {N: aws.String("-12345.6700")}},
+			expected: 1 + 4 + 1,
+		},
+		{
+			name:     "booleans and nulls are 1 byte",
+			item:     map[string]*dynamodb.AttributeValue{"b": {BOOL: aws.Bool(true)}, "z": {NULL: aws.Bool(true)}},
+			expected: 1 + 1 + 1 + 1,
+		},
+		{
+			name: "lists and maps have 3 bytes overhead plus 1 byte per element",
+			item: map[string]*dynamodb.AttributeValue{
+				"l": {L: []*dynamodb.AttributeValue{{S: aws.String("ab")}}},
+				"m": {M: map[string]*dynamodb.AttributeValue{"k": {S: aws.String("ab")}}},
+			},
+			expected: (1 + 3 + 1 + 2) + (1 + 3 + 1 + 1 + 2),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := ItemSize(tt.item); actual != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSizeFilter(t *testing.T) {
+	input := strings.Join([]string{
+		"a,b",
+		"1,small",
+		"2," + strings.Repeat("x", MaxItemSize),
+		"3,small",
+	}, "\n")
+	c, err := NewConverter(csv.NewReader(strings.NewReader(input)), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var rejectedLines []int64
+	sf := NewSizeFilter(c, func(line int64, size int) {
+		rejectedLines = append(rejectedLines, line)
+	})
+	var read int
+	for {
+		_, err = sf.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		read++
+	}
+	if read != 2 {
+		t.Errorf("expected 2 items to be read, got %d", read)
+	}
+	if len(rejectedLines) != 1 || rejectedLines[0] != 3 {
+		t.Errorf("expected line 3 to be rejected, got %v", rejectedLines)
+	}
+	stats := sf.Stats()
+	if stats.Rejected != 1 {
+		t.Errorf("expected 1 rejected item, got %d", stats.Rejected)
+	}
+	if stats.Max != 8 || stats.Mean() != 8 {
+		t.Errorf("expected max and mean of 8, got %d and %v", stats.Max, stats.Mean())
+	}
+}
+
+func TestSizeStatsPercentile(t *testing.T) {
+	var s SizeStats
+	for i := 0; i < 99; i++ {
+		s.Add(100)
+	}
+	s.Add(10000)
+	if p99 := s.Percentile(99); p99 != 128 {
+		t.Errorf("expected p99 to be within the 64 byte bucket containing 100, got %d", p99)
+	}
+	if p100 := s.Percentile(100); p100 != 10000 {
+		t.Errorf("expected p100 to be the max, got %d", p100)
+	}
+}
+
+func TestWriteUnits(t *testing.T) {
+	var s SizeStats
+	s.Add(1)
+	s.Add(1024)
+	s.Add(1025)
+	if s.WriteUnits != 4 {
+		t.Errorf("expected 4 write units, got %d", s.WriteUnits)
+	}
+}
+
+func TestSizeStatsMerge(t *testing.T) {
+	var a, b, empty SizeStats
+	for i := 0; i < 99; i++ {
+		a.Add(100)
+	}
+	b.Add(10000)
+	b.Rejected = 1
+	var merged SizeStats
+	merged.Merge(&empty)
+	merged.Merge(&a)
+	merged.Merge(&b)
+	if merged.Count != 100 || merged.Total != 99*100+10000 || merged.Max != 10000 || merged.Rejected != 1 {
+		t.Errorf("unexpected merged statistics: %+v", merged)
+	}
+	if p99 := merged.Percentile(99); p99 != 128 {
+		t.Errorf("expected p99 to be within the 64 byte bucket containing 100, got %d", p99)
+	}
+}
+
+func TestSizeStatsHistogram(t *testing.T) {
+	var a SizeStats
+	for i := 0; i < 99; i++ {
+		a.Add(100)
+	}
+	a.Add(10000)
+	a.Add(MaxItemSize)
+	expected := []int64{0, 99, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1}
+	if diff := cmp.Diff(expected, a.Histogram()); diff != "" {
+		t.Fatal(diff)
+	}
+	// Statistics sent by another process are combined from their totals and histograms.
+	combined := SizeStats{Count: a.Count, Total: a.Total, Max: a.Max}
+	combined.AddHistogram(a.Histogram())
+	combined.AddHistogram(nil)
+	if p50 := combined.Percentile(50); p50 != 128 {
+		t.Errorf("expected p50 to be the top of the bucket containing 100, got %d", p50)
+	}
+	if p99 := combined.Percentile(99); p99 != 16384 {
+		t.Errorf("expected p99 to be the top of the bucket containing 10000, got %d", p99)
+	}
+	if p100 := combined.Percentile(100); p100 != MaxItemSize {
+		t.Errorf("expected p100 to be the maximum size, got %d", p100)
+	}
+	if combined.Mean() != a.Mean() {
+		t.Errorf("expected mean %v, got %v", a.Mean(), combined.Mean())
+	}
+}
@@ -0,0 +1,134 @@
+package dedupe
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/a-h/ddbimport/keys"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Policy for handling records with duplicate keys within a batch.
+type Policy string
+
+const (
+	// LastWins replaces the earlier record in the batch with the later one.
+	LastWins Policy = "lastWins"
+	// FirstWins keeps the earlier record in the batch and drops the later one.
+	FirstWins Policy = "firstWins"
+	// Error stops reading and returns a DuplicateKeyError.
+	Error Policy = "error"
+)
+
+// ParsePolicy parses a Policy, returning an error if it's not known.
+func ParsePolicy(s string) (p Policy, err error) {
+	p = Policy(s)
+	switch p {
+	case LastWins, FirstWins, Error:
+		return
+	}
+	return p, fmt.Errorf("dedupe: unknown policy %q", s)
+}
+
+// DuplicateKeyError is returned when a batch contains two records with the same key and the policy is Error.
+type DuplicateKeyError struct {
+	Key        string
+	FirstLine  int64
+	SecondLine int64
+	// Offset is the byte offset of the range of the file that the lines are relative to.
+	Offset int64
+}
+
+func (e DuplicateKeyError) Error() string {
+	if e.Offset > 0 {
+		return fmt.Sprintf("dedupe: duplicate key %s on lines %d and %d of the range starting at byte %d", e.Key, e.FirstLine, e.SecondLine, e.Offset)
+	}
+	return fmt.Sprintf("dedupe: duplicate key %s on lines %d and %d", e.Key, e.FirstLine, e.SecondLine)
+}
+
+// ItemReader reads items, keeping track of the line number of the last item read.
+type ItemReader interface {
+	Read() (item map[string]*dynamodb.AttributeValue, err error)
+	Line() int64
+}
+
+// New creates a Reader which reads batches of items from r that don't contain duplicate keys,
+// since BatchWriteItem rejects batches that do. keyNames are the names of the table's key attributes.
+func New(r ItemReader, keyNames []string, policy Policy) *Reader {
+	return &Reader{
+		r:        r,
+		keyNames: keyNames,
+		policy:   policy,
+	}
+}
+
+// Reader reads batches of items with unique keys.
+type Reader struct {
+	r        ItemReader
+	keyNames []string
+	policy   Policy
+	// Offset is the byte offset within the file that the line numbers of the ItemReader are
+	// relative to, when it reads a range of the file.
+	Offset int64
+}
+
+// ReadBatch reads up to 25 items with unique keys.
+func (r *Reader) ReadBatch() (items []map[string]*dynamodb.AttributeValue, read int, err error) {
+	batchSize := 25
+	items = make([]map[string]*dynamodb.AttributeValue, 0, batchSize)
+	keyToIndex := make(map[string]int, batchSize)
+	lines := make([]int64, 0, batchSize)
+	for len(items) < batchSize {
+		var item map[string]*dynamodb.AttributeValue
+		item, err = r.r.Read()
+		if err != nil {
+			break
+		}
+		k, ok := Key(item, r.keyNames)
+		if !ok {
+			// Items without keys can't be duplicates, DynamoDB will reject them later.
+			items = append(items, item)
+			lines = append(lines, r.r.Line())
+			continue
+		}
+		i, isDuplicate := keyToIndex[k]
+		if !isDuplicate {
+			keyToIndex[k] = len(items)
+			items = append(items, item)
+			lines = append(lines, r.r.Line())
+			continue
+		}
+		switch r.policy {
+		case LastWins:
+			items[i] = item
+			lines[i] = r.r.Line()
+		case Error:
+			err = DuplicateKeyError{Key: k, FirstLine: lines[i], SecondLine: r.r.Line(), Offset: r.Offset}
+			return items, len(items), err
+		}
+	}
+	return items, len(items), err
+}
+
+// Key returns a string representation of the item's key, normalising numbers, since
+// DynamoDB considers 1 and 1.0 to be the same value.
+func Key(item map[string]*dynamodb.AttributeValue, keyNames []string) (k string, ok bool) {
+	values := make([]string, len(keyNames))
+	for i, name := range keyNames {
+		v, hasKey := item[name]
+		if !hasKey {
+			return
+		}
+		switch {
+		case v.S != nil:
+			values[i] = fmt.Sprintf("%s=%q", name, *v.S)
+		case v.N != nil:
+			values[i] = fmt.Sprintf("%s=%s", name, keys.NormalizeNumber(*v.N))
+		case v.B != nil:
+			values[i] = fmt.Sprintf("%s=%x", name, v.B)
+		default:
+			return
+		}
+	}
+	return strings.Join(values, ", "), true
+}
@@ -0,0 +1,128 @@
+package dedupe
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/google/go-cmp/cmp"
+)
+
+type mockItemReader struct {
+	items []map[string]*dynamodb.AttributeValue
+	line  int64
+}
+
+func (m *mockItemReader) Read() (item map[string]*dynamodb.AttributeValue, err error) {
+	if int(m.line) >= len(m.items) {
+		return nil, io.EOF
+	}
+	item = m.items[m.line]
+	m.line++
+	return
+}
+
+func (m *mockItemReader) Line() int64 {
+	return m.line
+}
+
+func item(id, year, value string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"id":    {S: aws.String(id)},
+		"year":  {N: aws.String(year)},
+		"value": {S: aws.String(value)},
+	}
+}
+
+func TestReader(t *testing.T) {
+	input := []map[string]*dynamodb.AttributeValue{
+		item("a", "2020", "first"),
+		item("b", "2020", "unique"),
+		item("a", "2020.0", "second"),
+		item("a", "2021", "different sort key"),
+	}
+	var tests = []struct {
+		policy        Policy
+		expected      []map[string]*dynamodb.AttributeValue
+		expectedError error
+	}{
+		{
+			policy: LastWins,
+			expected: []map[string]*dynamodb.AttributeValue{
+				item("a", "2020.0", "second"),
+				item("b", "2020", "unique"),
+				item("a", "2021", "different sort key"),
+			},
+			expectedError: io.EOF,
+		},
+		{
+			policy: FirstWins,
+			expected: []map[string]*dynamodb.AttributeValue{
+				item("a", "2020", "first"),
+				item("b", "2020", "unique"),
+				item("a", "2021", "different sort key"),
+			},
+			expectedError: io.EOF,
+		},
+		{
+			policy: Error,
+			expected: []map[string]*dynamodb.AttributeValue{
+				item("a", "2020", "first"),
+				item("b", "2020", "unique"),
+			},
+			expectedError: DuplicateKeyError{Key: `id="a", year=2020`, FirstLine: 1, SecondLine: 3},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(string(tt.policy), func(t *testing.T) {
+			r := New(&mockItemReader{items: input}, []string{"id", "year"}, tt.policy)
+			actual, read, err := r.ReadBatch()
+			if !errors.Is(err, tt.expectedError) {
+				t.Errorf("expected error %v, got %v", tt.expectedError, err)
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+			if read != len(tt.expected) {
+				t.Errorf("expected %d items to be read, got %d", len(tt.expected), read)
+			}
+		})
+	}
+}
+
+func TestReaderBatchesAreFull(t *testing.T) {
+	var input []map[string]*dynamodb.AttributeValue
+	for i := 0; i < 30; i++ {
+		input = append(input, item("a", "1", "duplicate"))
+	}
+	input = append(input, item("b", "1", "unique"))
+	r := New(&mockItemReader{items: input}, []string{"id", "year"}, FirstWins)
+	_, read, err := r.ReadBatch()
+	if err != io.EOF {
+		t.Errorf("expected EOF, got %v", err)
+	}
+	if read != 2 {
+		t.Errorf("expected duplicates to be skipped, so that 2 items are read, got %d", read)
+	}
+}
+
+func TestReaderRangeOffset(t *testing.T) {
+	input := []map[string]*dynamodb.AttributeValue{
+		item("a", "2020", "first"),
+		item("a", "2020", "second"),
+	}
+	r := New(&mockItemReader{items: input}, []string{"id", "year"}, Error)
+	r.Offset = 1024
+	_, _, err := r.ReadBatch()
+	expected := DuplicateKeyError{Key: `id="a", year=2020`, FirstLine: 1, SecondLine: 2, Offset: 1024}
+	if diff := cmp.Diff(expected, err); diff != "" {
+		t.Fatal(diff)
+	}
+	expectedMessage := `dedupe: duplicate key id="a", year=2020 on lines 1 and 2 of the range starting at byte 1024`
+	if err.Error() != expectedMessage {
+		t.Errorf("expected %q, got %q", expectedMessage, err.Error())
+	}
+}
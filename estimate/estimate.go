@@ -0,0 +1,98 @@
+package estimate
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Input to the estimate.
+type Input struct {
+	// SourceBytes is the size of the file.
+	SourceBytes int64
+	// SampleBytes is the number of bytes of the file that were sampled.
+	SampleBytes int64
+	// SampleRecords is the number of records in the sample.
+	SampleRecords int64
+	// SampleWriteUnits is the number of write capacity units required to write the sampled records.
+	SampleWriteUnits int64
+	// SampleItemBytes is the total size of the sampled records, once converted to DynamoDB items.
+	SampleItemBytes int64
+	// WriteUnitMultiplier accounts for the extra capacity used by transactions and index writes.
+	WriteUnitMultiplier float64
+	// Workers is the number of write requests executed in parallel.
+	Workers int
+	// RecordsPerRequest is the number of records written by each request, defaults to 25, the
+	// maximum for BatchWriteItem.
+	RecordsPerRequest int
+	// RequestLatency is the expected duration of each write request.
+	RequestLatency time.Duration
+	// ProvisionedWriteCapacity of the table, or zero if the table uses on-demand capacity.
+	ProvisionedWriteCapacity int64
+	// PricePerMillionWriteUnits is the on-demand price in USD.
+	PricePerMillionWriteUnits float64
+}
+
+// Report of the estimate.
+type Report struct {
+	Records             int64   `json:"records"`
+	MeanItemBytes       float64 `json:"meanItemBytes"`
+	WriteUnitsPerRecord float64 `json:"writeUnitsPerRecord"`
+	WriteUnits          int64   `json:"writeUnits"`
+	OnDemandCostUSD     float64 `json:"onDemandCostUsd"`
+	// WriteUnitsPerSecond is the expected throughput.
+	WriteUnitsPerSecond float64 `json:"writeUnitsPerSecond"`
+	DurationSeconds     float64 `json:"durationSeconds"`
+	// LimitedBy is "workers" or "provisionedCapacity".
+	LimitedBy string `json:"limitedBy"`
+}
+
+// Estimate the cost and duration of an import by extrapolating from a sample of the file.
+func Estimate(in Input) (r Report) {
+	if in.SampleRecords == 0 || in.SampleBytes == 0 {
+		return
+	}
+	if in.WriteUnitMultiplier == 0 {
+		in.WriteUnitMultiplier = 1
+	}
+	if in.RecordsPerRequest == 0 {
+		in.RecordsPerRequest = 25
+	}
+	r.Records = int64(math.Round(float64(in.SourceBytes) / float64(in.SampleBytes) * float64(in.SampleRecords)))
+	r.MeanItemBytes = float64(in.SampleItemBytes) / float64(in.SampleRecords)
+	r.WriteUnitsPerRecord = float64(in.SampleWriteUnits) / float64(in.SampleRecords) * in.WriteUnitMultiplier
+	r.WriteUnits = int64(math.Ceil(float64(r.Records) * r.WriteUnitsPerRecord))
+	r.OnDemandCostUSD = float64(r.WriteUnits) / 1000000 * in.PricePerMillionWriteUnits
+
+	if in.RequestLatency > 0 {
+		recordsPerSecond := float64(in.Workers*in.RecordsPerRequest) / in.RequestLatency.Seconds()
+		r.WriteUnitsPerSecond = recordsPerSecond * r.WriteUnitsPerRecord
+	}
+	r.LimitedBy = "workers"
+	if in.ProvisionedWriteCapacity > 0 && float64(in.ProvisionedWriteCapacity) < r.WriteUnitsPerSecond {
+		r.WriteUnitsPerSecond = float64(in.ProvisionedWriteCapacity)
+		r.LimitedBy = "provisionedCapacity"
+	}
+	if r.WriteUnitsPerSecond > 0 {
+		r.DurationSeconds = float64(r.WriteUnits) / r.WriteUnitsPerSecond
+	}
+	return
+}
+
+// Duration of the import.
+func (r Report) Duration() time.Duration {
+	return time.Duration(r.DurationSeconds * float64(time.Second))
+}
+
+func (r Report) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Estimated records:      %d\n", r.Records)
+	fmt.Fprintf(&sb, "Mean item size:         %.0f bytes\n", r.MeanItemBytes)
+	fmt.Fprintf(&sb, "Write units per record: %.2f\n", r.WriteUnitsPerRecord)
+	fmt.Fprintf(&sb, "Total write units:      %d\n", r.WriteUnits)
+	fmt.Fprintf(&sb, "On-demand cost:         $%.2f\n", r.OnDemandCostUSD)
+	fmt.Fprintf(&sb, "Throughput:             %.0f write units per second (limited by %s)\n", r.WriteUnitsPerSecond, r.LimitedBy)
+	fmt.Fprintf(&sb, "Expected duration:      %v\n", r.Duration().Round(time.Second))
+	return sb.String()
+}
@@ -0,0 +1,101 @@
+package estimate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEstimate(t *testing.T) {
+	var tests = []struct {
+		name     string
+		input    Input
+		expected Report
+	}{
+		{
+			name: "an empty sample results in an empty report",
+		},
+		{
+			name: "on-demand imports are limited by the number of workers",
+			input: Input{
+				SourceBytes:               1000000,
+				SampleBytes:               1000,
+				SampleRecords:             10,
+				SampleWriteUnits:          20,
+				SampleItemBytes:           15000,
+				Workers:                   8,
+				RequestLatency:            100 * time.Millisecond,
+				PricePerMillionWriteUnits: 1,
+			},
+			expected: Report{
+				Records:             10000,
+				MeanItemBytes:       1500,
+				WriteUnitsPerRecord: 2,
+				WriteUnits:          20000,
+				OnDemandCostUSD:     0.02,
+				WriteUnitsPerSecond: 4000, // 8 workers * 25 records * 10 batches per second * 2 units.
+				DurationSeconds:     5,
+				LimitedBy:           "workers",
+			},
+		},
+		{
+			name: "provisioned imports are limited by capacity",
+			input: Input{
+				SourceBytes:               1000000,
+				SampleBytes:               1000,
+				SampleRecords:             10,
+				SampleWriteUnits:          10,
+				SampleItemBytes:           1000,
+				WriteUnitMultiplier:       2,
+				Workers:                   8,
+				RequestLatency:            100 * time.Millisecond,
+				ProvisionedWriteCapacity:  100,
+				PricePerMillionWriteUnits: 1,
+			},
+			expected: Report{
+				Records:             10000,
+				MeanItemBytes:       100,
+				WriteUnitsPerRecord: 2,
+				WriteUnits:          20000,
+				OnDemandCostUSD:     0.02,
+				WriteUnitsPerSecond: 100,
+				DurationSeconds:     200,
+				LimitedBy:           "provisionedCapacity",
+			},
+		},
+		{
+			name: "single item requests write one record each",
+			input: Input{
+				SourceBytes:               1000,
+				SampleBytes:               1000,
+				SampleRecords:             100,
+				SampleWriteUnits:          100,
+				SampleItemBytes:           10000,
+				Workers:                   10,
+				RecordsPerRequest:         1,
+				RequestLatency:            10 * time.Millisecond,
+				PricePerMillionWriteUnits: 1,
+			},
+			expected: Report{
+				Records:             100,
+				MeanItemBytes:       100,
+				WriteUnitsPerRecord: 1,
+				WriteUnits:          100,
+				OnDemandCostUSD:     0.0001,
+				WriteUnitsPerSecond: 1000,
+				DurationSeconds:     0.1,
+				LimitedBy:           "workers",
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			actual := Estimate(tt.input)
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
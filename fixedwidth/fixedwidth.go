@@ -0,0 +1,46 @@
+package fixedwidth
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/a-h/ddbimport/textformat"
+)
+
+// Reader reads records from a fixed-width file, one line per record. Blank lines are ignored.
+type Reader struct {
+	r      *bufio.Reader
+	layout textformat.Layout
+	line   int64
+}
+
+// NewReader creates a Reader of the fixed-width records in r.
+func NewReader(r io.Reader, l textformat.Layout) *Reader {
+	return &Reader{
+		r:      bufio.NewReader(r),
+		layout: l,
+	}
+}
+
+// Read the next record.
+func (r *Reader) Read() (record []string, err error) {
+	for {
+		var line string
+		line, err = r.r.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return nil, err
+		}
+		r.line++
+		if r.line == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" {
+			return r.layout.Record(line), nil
+		}
+		if err == io.EOF {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package fixedwidth
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/a-h/ddbimport/textformat"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReader(t *testing.T) {
+	l := textformat.Layout{Fields: []textformat.Field{{Name: "id", Start: 1, Length: 2}, {Name: "name", Start: 3, Length: 5}}}
+	input := "\ufeff01Alice\r\n\n02Bob  \r\n03Eve"
+	r := NewReader(strings.NewReader(input), l)
+	var actual [][]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		actual = append(actual, record)
+	}
+	expected := [][]string{{"01", "Alice"}, {"02", "Bob"}, {"03", "Eve"}}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -10,8 +10,9 @@ require (
 	github.com/kr/text v0.2.0 // indirect
 	github.com/niemeyer/pretty v0.0.0-20200227124842-a10e7caefd8e // indirect
 	github.com/rakyll/statik v0.1.7
+	github.com/xitongsys/parquet-go v1.6.2
 	go.uber.org/zap v1.15.0
-	golang.org/x/text v0.3.8 // indirect
+	golang.org/x/text v0.14.0
 	gopkg.in/check.v1 v1.0.0-20200227125254-8fa46927fb4f // indirect
 	gopkg.in/yaml.v2 v2.2.8 // indirect
 )
@@ -0,0 +1,101 @@
+package jsonltodynamo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+var bom = []byte("\ufeff")
+
+// Converter converts JSON Lines (one JSON object per line) to DynamoDB records.
+type Converter struct {
+	r    *bufio.Reader
+	line int64
+}
+
+// NewConverter creates a new JSON Lines to DynamoDB converter.
+func NewConverter(r io.Reader) *Converter {
+	return &Converter{
+		r: bufio.NewReader(r),
+	}
+}
+
+// ReadBatch reads 25 items from the JSON Lines input.
+func (c *Converter) ReadBatch() (items []map[string]*dynamodb.AttributeValue, read int, err error) {
+	batchSize := 25
+	items = make([]map[string]*dynamodb.AttributeValue, batchSize)
+	for read = 0; read < batchSize; read++ {
+		items[read], err = c.Read()
+		if err != nil {
+			break
+		}
+	}
+	return items[:read], read, err
+}
+
+// Read a single item. Blank lines are skipped.
+// JSON strings, numbers, booleans and nulls are converted to S, N, BOOL and NULL attributes,
+// arrays are converted to L attributes and objects are converted to M attributes.
+func (c *Converter) Read() (item map[string]*dynamodb.AttributeValue, err error) {
+	var line []byte
+	for len(line) == 0 {
+		if line, err = c.r.ReadBytes('\n'); err != nil && (err != io.EOF || len(line) == 0) {
+			return
+		}
+		c.line++
+		if c.line == 1 {
+			// Windows tools add a UTF-8 byte order mark to the start of files.
+			line = bytes.TrimPrefix(line, bom)
+		}
+		line = bytes.TrimSpace(line)
+		if err == io.EOF && len(line) == 0 {
+			return
+		}
+	}
+	d := json.NewDecoder(bytes.NewReader(line))
+	d.UseNumber()
+	var v map[string]interface{}
+	if err = d.Decode(&v); err != nil {
+		err = fmt.Errorf("jsonltodynamo: line %d: %w", c.line, err)
+		return
+	}
+	item = make(map[string]*dynamodb.AttributeValue, len(v))
+	for k, vv := range v {
+		item[k] = dynamoValue(vv)
+	}
+	return item, nil
+}
+
+// Line returns the line number of the last item read, starting at 1.
+func (c *Converter) Line() int64 {
+	return c.line
+}
+
+func dynamoValue(v interface{}) *dynamodb.AttributeValue {
+	switch v := v.(type) {
+	case string:
+		return (&dynamodb.AttributeValue{}).SetS(v)
+	case json.Number:
+		return (&dynamodb.AttributeValue{}).SetN(v.String())
+	case bool:
+		return (&dynamodb.AttributeValue{}).SetBOOL(v)
+	case []interface{}:
+		l := make([]*dynamodb.AttributeValue, len(v))
+		for i, vv := range v {
+			l[i] = dynamoValue(vv)
+		}
+		return (&dynamodb.AttributeValue{}).SetL(l)
+	case map[string]interface{}:
+		m := make(map[string]*dynamodb.AttributeValue, len(v))
+		for k, vv := range v {
+			m[k] = dynamoValue(vv)
+		}
+		return (&dynamodb.AttributeValue{}).SetM(m)
+	}
+	return (&dynamodb.AttributeValue{}).SetNULL(true)
+}
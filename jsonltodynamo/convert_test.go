@@ -0,0 +1,93 @@
+package jsonltodynamo
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConverter(t *testing.T) {
+	var tests = []struct {
+		name          string
+		input         string
+		expected      []map[string]*dynamodb.AttributeValue
+		expectedError bool
+	}{
+		{
+			name:  "scalar types are converted",
+			input: `{"s":"the","n":123.45,"b":true,"z":null}`,
+			expected: []map[string]*dynamodb.AttributeValue{
+				{
+					"s": &dynamodb.AttributeValue{S: aws.String("the")},
+					"n": &dynamodb.AttributeValue{N: aws.String("123.45")},
+					"b": &dynamodb.AttributeValue{BOOL: aws.Bool(true)},
+					"z": &dynamodb.AttributeValue{NULL: aws.Bool(true)},
+				},
+			},
+		},
+		{
+			name:  "arrays and objects are converted to lists and maps",
+			input: `{"l":["a",1],"m":{"x":"y"}}`,
+			expected: []map[string]*dynamodb.AttributeValue{
+				{
+					"l": &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{
+						{S: aws.String("a")},
+						{N: aws.String("1")},
+					}},
+					"m": &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{
+						"x": {S: aws.String("y")},
+					}},
+				},
+			},
+		},
+		{
+			name:  "byte order marks and Windows line endings are removed",
+			input: "\ufeff" + `{"a":"1"}` + "\r\n" + `{"a":"2"}` + "\r\n",
+			expected: []map[string]*dynamodb.AttributeValue{
+				{"a": &dynamodb.AttributeValue{S: aws.String("1")}},
+				{"a": &dynamodb.AttributeValue{S: aws.String("2")}},
+			},
+		},
+		{
+			name: "blank lines are skipped",
+			input: strings.Join([]string{
+				`{"a":"1"}`,
+				``,
+				`{"a":"2"}`,
+				``,
+			}, "\n"),
+			expected: []map[string]*dynamodb.AttributeValue{
+				{"a": &dynamodb.AttributeValue{S: aws.String("1")}},
+				{"a": &dynamodb.AttributeValue{S: aws.String("2")}},
+			},
+		},
+		{
+			name:          "invalid JSON returns an error",
+			input:         `{"a":`,
+			expectedError: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConverter(strings.NewReader(tt.input))
+			actual, read, err := c.ReadBatch()
+			if tt.expectedError {
+				if err == nil || err == io.EOF {
+					t.Fatalf("expected error, got %v", err)
+				}
+				return
+			}
+			if err != io.EOF {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, actual[:read]); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package keys
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// NormalizeNumber returns a canonical representation of a DynamoDB number, since DynamoDB considers
+// 1 and 1.0 to be the same value. Numbers that can't be parsed are returned unchanged.
+func NormalizeNumber(n string) string {
+	f, _, err := big.ParseFloat(n, 10, 256, big.ToNearestEven)
+	if err != nil {
+		return n
+	}
+	return f.Text('g', -1)
+}
+
+var (
+	minNumber, _, _ = big.ParseFloat("1E-130", 10, 256, big.ToNearestEven)
+	maxNumber, _, _ = big.ParseFloat("1E+126", 10, 256, big.ToNearestEven)
+)
+
+// ValidNumber returns an error if DynamoDB can't store the value as a number, i.e. it has more than
+// 38 significant digits, or it isn't zero and its magnitude is outside the range 1E-130 to 1E+126.
+func ValidNumber(n string) error {
+	f, _, err := big.ParseFloat(n, 10, 256, big.ToNearestEven)
+	if err != nil || f.IsInf() {
+		return fmt.Errorf("keys: %q is not a number", n)
+	}
+	mantissa := strings.SplitN(strings.ToLower(strings.TrimLeft(n, "+-")), "e", 2)[0]
+	if digits := strings.Trim(strings.Replace(mantissa, ".", "", 1), "0"); len(digits) > 38 {
+		return fmt.Errorf("keys: %q has more than 38 significant digits", n)
+	}
+	if f.Sign() == 0 {
+		return nil
+	}
+	if abs := new(big.Float).Abs(f); abs.Cmp(minNumber) < 0 || abs.Cmp(maxNumber) >= 0 {
+		return fmt.Errorf("keys: %q is outside the range of DynamoDB numbers, 1E-130 to 1E+126", n)
+	}
+	return nil
+}
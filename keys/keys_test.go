@@ -0,0 +1,46 @@
+package keys
+
+import "testing"
+
+func TestNormalizeNumber(t *testing.T) {
+	var tests = []struct {
+		input    string
+		expected string
+	}{
+		{input: "1", expected: "1"},
+		{input: "1.0", expected: "1"},
+		{input: "1.50", expected: "1.5"},
+		{input: "1e2", expected: "100"},
+		{input: "-0.001", expected: "-0.001"},
+		{input: "not a number", expected: "not a number"},
+	}
+	for _, tt := range tests {
+		if actual := NormalizeNumber(tt.input); actual != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestValidNumber(t *testing.T) {
+	var tests = []struct {
+		input    string
+		expected bool
+	}{
+		{input: "0", expected: true},
+		{input: "-12.5", expected: true},
+		{input: "1E-130", expected: true},
+		{input: "9.9999999999999999999999999999999999999E+125", expected: true},
+		{input: "12345678901234567890123456789012345678", expected: true},
+		{input: "123456789012345678901234567890123456789", expected: false},
+		{input: "1E+126", expected: false},
+		{input: "1E+300", expected: false},
+		{input: "-1E-131", expected: false},
+		{input: "NaN", expected: false},
+		{input: "a", expected: false},
+	}
+	for _, tt := range tests {
+		if err := ValidNumber(tt.input); (err == nil) != tt.expected {
+			t.Errorf("%q: expected valid %v, got error %v", tt.input, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package local
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/a-h/ddbimport/batchwriter"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"go.uber.org/zap"
+)
+
+// Job is a batch to write, and a function to call once it's been written.
+type Job struct {
+	Batch []map[string]*dynamodb.AttributeValue
+	Done  func()
+}
+
+// Pool writes batches to a target using its own workers, so that a failure of one target doesn't
+// stop the others.
+type Pool struct {
+	Target
+	Logger     *zap.Logger
+	Writer     batchwriter.Writer
+	batches    chan Job
+	failed     chan struct{}
+	failOnce   sync.Once
+	err        error
+	wg         sync.WaitGroup
+	records    int64
+	batchCount int64
+}
+
+// NewPool creates a Pool with its own queue of up to queueSize batches.
+func NewPool(t Target, logger *zap.Logger, writer batchwriter.Writer, queueSize int) *Pool {
+	return &Pool{
+		Target:  t,
+		Logger:  logger,
+		Writer:  writer,
+		batches: make(chan Job, queueSize),
+		failed:  make(chan struct{}),
+	}
+}
+
+// Start the workers.
+func (p *Pool) Start(concurrency int, start time.Time) {
+	p.wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func(workerIndex int) {
+			defer p.wg.Done()
+			for job := range p.batches {
+				select {
+				case <-p.failed:
+					return
+				default:
+				}
+				err := p.Writer.Write(job.Batch)
+				if err != nil {
+					p.Logger.Error("error executing batch write", zap.Int("workerIndex", workerIndex), zap.Error(err))
+					p.fail(err)
+					return
+				}
+				if job.Done != nil {
+					job.Done()
+				}
+				recordCount := atomic.AddInt64(&p.records, int64(len(job.Batch)))
+				if batchCount := atomic.AddInt64(&p.batchCount, 1); batchCount%100 == 0 {
+					p.Logger.Info("progress", zap.Int("workerIndex", workerIndex), zap.Int64("records", recordCount), zap.Int("rps", int(float64(recordCount)/time.Since(start).Seconds())))
+				}
+			}
+		}(i)
+	}
+}
+
+func (p *Pool) fail(err error) {
+	p.failOnce.Do(func() {
+		p.err = err
+		close(p.failed)
+	})
+}
+
+// hasFailed returns true if a write has failed, even if there's space in the queue.
+func (p *Pool) hasFailed() bool {
+	select {
+	case <-p.failed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Send the batch to the workers, returning false if the target has failed.
+func (p *Pool) Send(job Job) bool {
+	if p.hasFailed() {
+		return false
+	}
+	select {
+	case p.batches <- job:
+		return true
+	case <-p.failed:
+		return false
+	}
+}
+
+// SendAll sends the job to each pool whose target hasn't failed, returning false if all of them have
+// failed. Pools with space in their queue receive the job first, so that a throttled target only
+// holds up the others once its queue is full.
+func SendAll(pools []*Pool, job Job) (sent bool) {
+	var full []*Pool
+	for _, p := range pools {
+		if p.hasFailed() {
+			continue
+		}
+		select {
+		case p.batches <- job:
+			sent = true
+		default:
+			full = append(full, p)
+		}
+	}
+	for _, p := range full {
+		if p.Send(job) {
+			sent = true
+		}
+	}
+	return
+}
+
+// Wait for the workers to complete, returning the error that stopped them, if any.
+func (p *Pool) Wait() error {
+	close(p.batches)
+	p.wg.Wait()
+	return p.err
+}
+
+// Records returns the number of records that have been written.
+func (p *Pool) Records() int64 {
+	return atomic.LoadInt64(&p.records)
+}
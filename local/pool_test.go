@@ -0,0 +1,69 @@
+package local
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"go.uber.org/zap"
+)
+
+type writerFunc func(records []map[string]*dynamodb.AttributeValue) error
+
+func (f writerFunc) Write(records []map[string]*dynamodb.AttributeValue) error {
+	return f(records)
+}
+
+func TestPool(t *testing.T) {
+	var m sync.Mutex
+	var written int
+	ok := NewPool(Target{Region: "eu-west-2", TableName: "ok"}, zap.NewNop(), writerFunc(func(records []map[string]*dynamodb.AttributeValue) error {
+		m.Lock()
+		defer m.Unlock()
+		written += len(records)
+		return nil
+	}), 1)
+	errFailed := errors.New("failed")
+	failing := NewPool(Target{Region: "eu-west-2", TableName: "failing"}, zap.NewNop(), writerFunc(func(records []map[string]*dynamodb.AttributeValue) error {
+		return errFailed
+	}), 1)
+	pools := []*Pool{ok, failing}
+	for _, p := range pools {
+		p.Start(2, time.Now())
+	}
+	var done int
+	for i := 0; i < 10; i++ {
+		job := Job{
+			Batch: []map[string]*dynamodb.AttributeValue{{}},
+			Done: func() {
+				m.Lock()
+				defer m.Unlock()
+				done++
+			},
+		}
+		if !SendAll(pools, job) {
+			t.Fatalf("expected the job to be sent to the pool that hasn't failed")
+		}
+	}
+	<-failing.failed
+	if SendAll([]*Pool{failing}, Job{}) {
+		t.Errorf("expected jobs not to be sent once every pool has failed")
+	}
+	if err := ok.Wait(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := failing.Wait(); err != errFailed {
+		t.Errorf("expected the error of the failed writer, got %v", err)
+	}
+	if written != 10 || ok.Records() != 10 {
+		t.Errorf("expected 10 records to be written, got %d, and %d recorded", written, ok.Records())
+	}
+	if done != 10 {
+		t.Errorf("expected each job to be done once, got %d", done)
+	}
+	if failing.Records() != 0 {
+		t.Errorf("expected the failed pool to write no records, got %d", failing.Records())
+	}
+}
@@ -0,0 +1,67 @@
+package local
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/a-h/ddbimport/checkpoint"
+	"github.com/a-h/ddbimport/s3reader"
+)
+
+// Source is an input file that can be read from any offset.
+type Source struct {
+	io.ReaderAt
+	Size int64
+	// Version of the file, which is saved in checkpoints.
+	Version   checkpoint.Version
+	openRange func(start, end int64) (io.ReadCloser, error)
+}
+
+// Opener returns a function that opens the byte range of the input, from start (inclusive) to end
+// (exclusive).
+func (src Source) Opener(start, end int64) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		if start >= end {
+			return ioutil.NopCloser(strings.NewReader("")), nil
+		}
+		return src.openRange(start, end)
+	}
+}
+
+// OpenFile opens a local file. The file is left open, since it's read until the import completes.
+func OpenFile(name string) (src Source, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return
+	}
+	src = Source{
+		ReaderAt: f,
+		Size:     fi.Size(),
+		Version:  checkpoint.Version{Size: fi.Size(), ModTime: fi.ModTime()},
+		openRange: func(start, end int64) (io.ReadCloser, error) {
+			return ioutil.NopCloser(io.NewSectionReader(f, start, end-start)), nil
+		},
+	}
+	return
+}
+
+// OpenS3 reads an S3 object using byte range requests.
+func OpenS3(region, bucket, key string) (src Source, err error) {
+	o, err := s3reader.Open(region, bucket, key)
+	if err != nil {
+		return
+	}
+	src = Source{
+		ReaderAt:  o,
+		Size:      o.Size,
+		Version:   checkpoint.Version{Size: o.Size, ModTime: o.LastModified, ETag: o.ETag},
+		openRange: o.OpenRange,
+	}
+	return
+}
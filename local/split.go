@@ -0,0 +1,78 @@
+package local
+
+import (
+	"io"
+
+	"github.com/a-h/ddbimport/checkpoint"
+	"github.com/a-h/ddbimport/parquettodynamo"
+	"github.com/a-h/ddbimport/sls/preflight/process"
+	"github.com/a-h/ddbimport/sls/state"
+)
+
+// Range of the input, from Start (inclusive) to End (exclusive). The range is of bytes, or of rows for
+// Parquet files.
+type Range struct {
+	Start int64
+	End   int64
+	Open  func() (io.ReadCloser, error)
+	// Checkpoint is the index of the range in the checkpoint.
+	Checkpoint int
+}
+
+// Split the input after the header into up to n ranges that start at the beginning of a record and
+// can be read in parallel, in the same way as the preflight Lambda. If the checkpoint file contains
+// the progress of a previous import, it's used instead.
+func Split(src Source, n int, headerEnd int64, source state.Source, columns int, checkpointFile string) (ranges []checkpoint.Range, err error) {
+	if checkpointFile != "" {
+		ranges, err = checkpoint.Load(checkpointFile, src.Version)
+		if err != nil || len(ranges) > 0 {
+			return
+		}
+	}
+	offsets, err := process.Ranges(src, src.Size, headerEnd, n, source, columns)
+	if err != nil {
+		return
+	}
+	for _, r := range offsets {
+		ranges = append(ranges, checkpoint.Range{Start: r[0], Offset: r[0], End: r[1]})
+	}
+	if checkpointFile != "" {
+		err = checkpoint.Save(checkpointFile, checkpoint.Checkpoint{Version: src.Version, Ranges: ranges})
+	}
+	return
+}
+
+// Remaining returns the ranges that haven't been completed, opened from their checkpointed offset.
+func Remaining(src Source, ranges []checkpoint.Range) (remaining []Range) {
+	for i, r := range ranges {
+		if r.Complete() {
+			continue
+		}
+		remaining = append(remaining, Range{Start: r.Offset, End: r.End, Open: src.Opener(r.Offset, r.End), Checkpoint: i})
+	}
+	return
+}
+
+// SplitRows splits the rows of a Parquet file into up to n ranges of a similar number of rows that
+// can be read in parallel, even if the file contains a single row group. The start and end of each
+// range are rows, which are read using random access, so nothing is streamed from the input.
+func SplitRows(src Source, n int) (ranges []Range, err error) {
+	f, err := parquettodynamo.Open(src, src.Size)
+	if err != nil {
+		return
+	}
+	total := f.Rows()
+	var from int64
+	for i := 1; i <= n; i++ {
+		to := total * int64(i) / int64(n)
+		if to > from {
+			ranges = append(ranges, Range{Start: from, End: to, Open: src.Opener(0, 0)})
+			from = to
+		}
+	}
+	if len(ranges) == 0 {
+		// Files without rows still need to be read to validate the columns.
+		ranges = []Range{{Start: 0, End: 0, Open: src.Opener(0, 0)}}
+	}
+	return
+}
@@ -0,0 +1,43 @@
+package local
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Target is a table that records are imported to.
+type Target struct {
+	Region    string
+	TableName string
+}
+
+// String returns the region and name of the table, e.g. eu-west-2/ddbimport.
+func (t Target) String() string {
+	return t.Region + "/" + t.TableName
+}
+
+// ParseTargets parses comma separated lists of regions and table names. If one list has a single
+// value, it's used for every value in the other list.
+func ParseTargets(regions, tableNames string) (targets []Target, err error) {
+	r := strings.Split(regions, ",")
+	t := strings.Split(tableNames, ",")
+	n := len(r)
+	if len(t) > n {
+		n = len(t)
+	}
+	if (len(r) != 1 && len(r) != n) || (len(t) != 1 && len(t) != n) {
+		err = fmt.Errorf("the number of table regions (%d) and table names (%d) must match", len(r), len(t))
+		return
+	}
+	for i := 0; i < n; i++ {
+		target := Target{Region: r[0], TableName: t[0]}
+		if len(r) > 1 {
+			target.Region = r[i]
+		}
+		if len(t) > 1 {
+			target.TableName = t[i]
+		}
+		targets = append(targets, target)
+	}
+	return
+}
@@ -0,0 +1,60 @@
+package local
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseTargets(t *testing.T) {
+	var tests = []struct {
+		name        string
+		regions     string
+		tableNames  string
+		expected    []Target
+		expectedErr bool
+	}{
+		{
+			name:       "a single table",
+			regions:    "eu-west-2",
+			tableNames: "ddbimport",
+			expected:   []Target{{Region: "eu-west-2", TableName: "ddbimport"}},
+		},
+		{
+			name:       "a single table name is used in every region",
+			regions:    "eu-west-2,us-east-1",
+			tableNames: "ddbimport",
+			expected:   []Target{{Region: "eu-west-2", TableName: "ddbimport"}, {Region: "us-east-1", TableName: "ddbimport"}},
+		},
+		{
+			name:       "a single region is used for every table",
+			regions:    "eu-west-2",
+			tableNames: "a,b",
+			expected:   []Target{{Region: "eu-west-2", TableName: "a"}, {Region: "eu-west-2", TableName: "b"}},
+		},
+		{
+			name:       "regions and table names are paired",
+			regions:    "eu-west-2,us-east-1",
+			tableNames: "a,b",
+			expected:   []Target{{Region: "eu-west-2", TableName: "a"}, {Region: "us-east-1", TableName: "b"}},
+		},
+		{
+			name:        "the number of regions and table names must match",
+			regions:     "eu-west-2,us-east-1",
+			tableNames:  "a,b,c",
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := ParseTargets(tt.regions, tt.tableNames)
+			if tt.expectedErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package parquettodynamo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/schema"
+)
+
+// readRows is the number of rows read from the file at a time.
+const readRows = 1000
+
+var magic = []byte("PAR1")
+
+// File is a Parquet file, which is read using random access, since the schema and the positions of
+// the row groups are stored at the end of the file.
+type File struct {
+	r       io.ReaderAt
+	size    int64
+	pr      *reader.ParquetReader
+	root    *node
+	rowType reflect.Type
+}
+
+// Open reads the schema and the row groups of a Parquet file.
+func Open(r io.ReaderAt, size int64) (f *File, err error) {
+	tail := make([]byte, len(magic))
+	if size < int64(2*len(magic)) {
+		return nil, errors.New("parquettodynamo: file is too small to be a Parquet file")
+	}
+	if _, err = r.ReadAt(tail, size-int64(len(magic))); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if !bytes.Equal(tail, magic) {
+		return nil, errors.New("parquettodynamo: not a Parquet file")
+	}
+	f = &File{r: r, size: size}
+	f.pr = &reader.ParquetReader{NP: 1, PFile: newReaderAtFile(r, size)}
+	if err = f.pr.ReadFooter(); err != nil {
+		return nil, fmt.Errorf("parquettodynamo: failed to read footer: %w", err)
+	}
+	f.pr.SchemaHandler = schema.NewSchemaHandlerFromSchemaList(f.pr.Footer.Schema)
+	f.pr.RenameSchema()
+	var i int
+	f.root = newNode(f.pr.SchemaHandler, &i)
+	if f.rowType, err = f.pr.SchemaHandler.GetType(f.pr.SchemaHandler.GetRootInName()); err != nil {
+		return nil, fmt.Errorf("parquettodynamo: %w", err)
+	}
+	return f, nil
+}
+
+// RowGroups returns the number of rows in each row group.
+func (f *File) RowGroups() (rows []int64) {
+	for _, rg := range f.pr.Footer.RowGroups {
+		rows = append(rows, rg.NumRows)
+	}
+	return rows
+}
+
+// Columns returns the names of the top level fields.
+func (f *File) Columns() []string {
+	columns := make([]string, len(f.root.children))
+	for i, c := range f.root.children {
+		columns[i] = c.name
+	}
+	return columns
+}
+
+// AttributeType returns the DynamoDB type that values of the top level field are converted to.
+func (f *File) AttributeType(column string) string {
+	for _, c := range f.root.children {
+		if c.name == column {
+			return c.attributeType()
+		}
+	}
+	return dynamodb.ScalarAttributeTypeS
+}
+
+// Rows returns the number of rows in the file.
+func (f *File) Rows() (rows int64) {
+	for _, rg := range f.pr.Footer.RowGroups {
+		rows += rg.NumRows
+	}
+	return rows
+}
+
+// NewConverter creates a converter of the rows from (inclusive) to (exclusive), starting at 0. If to
+// is negative, all of the remaining rows are converted. Only the row groups that contain the rows are
+// read, so large row groups can be split between converters.
+func (f *File) NewConverter(from, to int64) (c *Converter, err error) {
+	total := f.Rows()
+	if to < 0 || to > total {
+		to = total
+	}
+	if from < 0 || from > to {
+		return nil, fmt.Errorf("parquettodynamo: invalid rows %d to %d of %d", from, to, total)
+	}
+	groups := f.pr.Footer.RowGroups
+	first, last := len(groups), len(groups)
+	var skip, offset int64
+	for i, rg := range groups {
+		if offset+rg.NumRows > from && offset < to {
+			if first == len(groups) {
+				first, skip = i, from-offset
+			}
+			last = i + 1
+		}
+		offset += rg.NumRows
+	}
+	footer := *f.pr.Footer
+	footer.RowGroups = groups[first:last]
+	pr := &reader.ParquetReader{
+		SchemaHandler: f.pr.SchemaHandler,
+		NP:            1,
+		Footer:        &footer,
+		PFile:         f.pr.PFile,
+		ColumnBuffers: map[string]*reader.ColumnBufferType{},
+		ObjType:       f.rowType,
+	}
+	for i, e := range pr.SchemaHandler.SchemaElements {
+		if e.GetNumChildren() > 0 {
+			continue
+		}
+		path := pr.SchemaHandler.IndexMap[int32(i)]
+		if pr.ColumnBuffers[path], err = reader.NewColumnBuffer(pr.PFile, &footer, pr.SchemaHandler, path); err != nil {
+			return nil, fmt.Errorf("parquettodynamo: %w", err)
+		}
+	}
+	c = &Converter{f: f, pr: pr, skip: skip, line: from, rowCount: to - from, remaining: to - from}
+	return c, nil
+}
+
+// Converter converts the rows of a Parquet file to DynamoDB records.
+type Converter struct {
+	f    *File
+	pr   *reader.ParquetReader
+	rows []interface{}
+	// skip is the number of rows of the first row group before the rows that are converted.
+	skip      int64
+	rowCount  int64
+	remaining int64
+	line      int64
+	// NullAttributes converts null values to NULL attributes instead of omitting them.
+	NullAttributes bool
+}
+
+// ReadBatch reads 25 items from the Parquet file.
+func (c *Converter) ReadBatch() (items []map[string]*dynamodb.AttributeValue, read int, err error) {
+	batchSize := 25
+	items = make([]map[string]*dynamodb.AttributeValue, batchSize)
+	for read = 0; read < batchSize; read++ {
+		items[read], err = c.Read()
+		if err != nil {
+			break
+		}
+	}
+	return items[:read], read, err
+}
+
+// Read a single item. Numbers and decimals are converted to N attributes, strings, enums, JSON, dates,
+// times and timestamps to S attributes, booleans to BOOL attributes, and other byte arrays to B
+// attributes. Lists and repeated fields are converted to L attributes, and maps and groups are
+// converted to M attributes. Null fields are omitted. An error is returned for numbers that DynamoDB
+// can't store, such as NaN, infinities, and numbers outside the range 1E-130 to 1E+126.
+func (c *Converter) Read() (item map[string]*dynamodb.AttributeValue, err error) {
+	if len(c.rows) == 0 {
+		if c.remaining <= 0 {
+			return nil, io.EOF
+		}
+		// The rows of the first row group before from are read and discarded, since its pages
+		// can only be decoded from the start.
+		for c.skip > 0 {
+			n := int64(readRows)
+			if n > c.skip {
+				n = c.skip
+			}
+			if _, err = c.pr.ReadByNumber(int(n)); err != nil {
+				return nil, fmt.Errorf("parquettodynamo: row %d: %w", c.line+1, err)
+			}
+			c.skip -= n
+		}
+		n := int64(readRows)
+		if n > c.remaining {
+			n = c.remaining
+		}
+		if c.rows, err = c.pr.ReadByNumber(int(n)); err != nil {
+			return nil, fmt.Errorf("parquettodynamo: row %d: %w", c.line+1, err)
+		}
+		if len(c.rows) == 0 {
+			return nil, io.EOF
+		}
+		c.remaining -= int64(len(c.rows))
+	}
+	row := reflect.ValueOf(c.rows[0])
+	c.rows = c.rows[1:]
+	c.line++
+	item = make(map[string]*dynamodb.AttributeValue, len(c.f.root.children))
+	for i, col := range c.f.root.children {
+		av, err := col.convert(row.Field(i))
+		if err != nil {
+			return nil, fmt.Errorf("parquettodynamo: row %d: %s: %w", c.line, col.name, err)
+		}
+		if av == nil && c.NullAttributes {
+			av = nullValue
+		}
+		if av != nil {
+			item[col.name] = av
+		}
+	}
+	return item, nil
+}
+
+// Rows returns the number of rows that are converted.
+func (c *Converter) Rows() int64 {
+	return c.rowCount
+}
+
+// Line returns the number of the last row read within the file, starting at 1.
+func (c *Converter) Line() int64 {
+	return c.line
+}
+
+// Columns returns the names of the top level fields.
+func (c *Converter) Columns() []string {
+	return c.f.Columns()
+}
+
+// AttributeType returns the DynamoDB type that values of the top level field are converted to.
+func (c *Converter) AttributeType(column string) string {
+	return c.f.AttributeType(column)
+}
@@ -0,0 +1,242 @@
+package parquettodynamo
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/google/go-cmp/cmp"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+type address struct {
+	Street string `parquet:"name=street, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Zip    *int32 `parquet:"name=zip, type=INT32, repetitiontype=OPTIONAL"`
+}
+
+type row struct {
+	ID      int64            `parquet:"name=id, type=INT64"`
+	Name    *string          `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	Active  bool             `parquet:"name=active, type=BOOLEAN"`
+	Score   float64          `parquet:"name=score, type=DOUBLE"`
+	Price   int32            `parquet:"name=price, type=INT32, convertedtype=DECIMAL, scale=2, precision=9"`
+	Born    int32            `parquet:"name=born, type=INT32, convertedtype=DATE"`
+	Updated int64            `parquet:"name=updated, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	Data    string           `parquet:"name=data, type=BYTE_ARRAY"`
+	Tags    []string         `parquet:"name=tags, type=LIST, valuetype=BYTE_ARRAY, valueconvertedtype=UTF8"`
+	Counts  map[string]int32 `parquet:"name=counts, type=MAP, convertedtype=MAP, keytype=BYTE_ARRAY, keyconvertedtype=UTF8, valuetype=INT32"`
+	Address address          `parquet:"name=address"`
+	Aliases []string         `parquet:"name=aliases, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=REPEATED"`
+}
+
+// write the rows to a Parquet file, starting a new row group every groupSize rows.
+func write(t *testing.T, rows []row, groupSize int) *bytes.Reader {
+	var buf bytes.Buffer
+	pw, err := writer.NewParquetWriterFromWriter(&buf, new(row), 1)
+	if err != nil {
+		t.Fatalf("failed to create writer: %v", err)
+	}
+	for i, r := range rows {
+		if err = pw.Write(r); err != nil {
+			t.Fatalf("failed to write row: %v", err)
+		}
+		if (i+1)%groupSize == 0 {
+			if err = pw.Flush(true); err != nil {
+				t.Fatalf("failed to flush row group: %v", err)
+			}
+		}
+	}
+	if err = pw.WriteStop(); err != nil {
+		t.Fatalf("failed to write footer: %v", err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func readAll(t *testing.T, c *Converter) (items []map[string]*dynamodb.AttributeValue) {
+	for {
+		item, err := c.Read()
+		if err == io.EOF {
+			return items
+		}
+		if err != nil {
+			t.Fatalf("failed to read: %v", err)
+		}
+		items = append(items, item)
+	}
+}
+
+func TestConverter(t *testing.T) {
+	zip := int32(12345)
+	rows := []row{
+		{
+			ID:      1,
+			Name:    aws.String("Alice"),
+			Active:  true,
+			Score:   1.5,
+			Price:   -1999,
+			Born:    18262,
+			Updated: 1577836800123,
+			Data:    "\x00\x01",
+			Tags:    []string{"a", "b"},
+			Counts:  map[string]int32{"x": 1},
+			Address: address{Street: "High Street", Zip: &zip},
+			Aliases: []string{"Al"},
+		},
+		{
+			ID:      2,
+			Address: address{Street: "Low Street"},
+		},
+	}
+	if _, err := Open(write(t, rows, 10), 0); err == nil {
+		t.Fatal("expected an error opening an empty file")
+	}
+	r := write(t, rows, 10)
+	f, err := Open(r, r.Size())
+	if err != nil {
+		t.Fatalf("failed to open: %v", err)
+	}
+	expectedColumns := []string{"id", "name", "active", "score", "price", "born", "updated", "data", "tags", "counts", "address", "aliases"}
+	if diff := cmp.Diff(expectedColumns, f.Columns()); diff != "" {
+		t.Error(diff)
+	}
+	expectedTypes := []string{"N", "S", "BOOL", "N", "N", "S", "S", "B", "L", "M", "M", "L"}
+	for i, column := range expectedColumns {
+		if actual := f.AttributeType(column); actual != expectedTypes[i] {
+			t.Errorf("expected %q to be %s, got %s", column, expectedTypes[i], actual)
+		}
+	}
+	c, err := f.NewConverter(0, -1)
+	if err != nil {
+		t.Fatalf("failed to create converter: %v", err)
+	}
+	expected := []map[string]*dynamodb.AttributeValue{
+		{
+			"id":      {N: aws.String("1")},
+			"name":    {S: aws.String("Alice")},
+			"active":  {BOOL: aws.Bool(true)},
+			"score":   {N: aws.String("1.5")},
+			"price":   {N: aws.String("-19.99")},
+			"born":    {S: aws.String("2020-01-01")},
+			"updated": {S: aws.String("2020-01-01T00:00:00.123Z")},
+			"data":    {B: []byte{0, 1}},
+			"tags":    {L: []*dynamodb.AttributeValue{{S: aws.String("a")}, {S: aws.String("b")}}},
+			"counts":  {M: map[string]*dynamodb.AttributeValue{"x": {N: aws.String("1")}}},
+			"address": {M: map[string]*dynamodb.AttributeValue{
+				"street": {S: aws.String("High Street")},
+				"zip":    {N: aws.String("12345")},
+			}},
+			"aliases": {L: []*dynamodb.AttributeValue{{S: aws.String("Al")}}},
+		},
+		{
+			"id":      {N: aws.String("2")},
+			"active":  {BOOL: aws.Bool(false)},
+			"score":   {N: aws.String("0")},
+			"price":   {N: aws.String("0.00")},
+			"born":    {S: aws.String("1970-01-01")},
+			"updated": {S: aws.String("1970-01-01T00:00:00Z")},
+			"data":    {B: []byte{}},
+			"tags":    {L: []*dynamodb.AttributeValue{}},
+			"counts":  {M: map[string]*dynamodb.AttributeValue{}},
+			"address": {M: map[string]*dynamodb.AttributeValue{
+				"street": {S: aws.String("Low Street")},
+			}},
+		},
+	}
+	if diff := cmp.Diff(expected, readAll(t, c)); diff != "" {
+		t.Error(diff)
+	}
+	if c.Line() != 2 {
+		t.Errorf("expected line 2, got %d", c.Line())
+	}
+}
+
+func TestConverterRowGroups(t *testing.T) {
+	var rows []row
+	for i := 0; i < 7; i++ {
+		rows = append(rows, row{ID: int64(i)})
+	}
+	r := write(t, rows, 3)
+	f, err := Open(r, r.Size())
+	if err != nil {
+		t.Fatalf("failed to open: %v", err)
+	}
+	if diff := cmp.Diff([]int64{3, 3, 1}, f.RowGroups()); diff != "" {
+		t.Fatal(diff)
+	}
+	var tests = []struct {
+		name         string
+		from, to     int64
+		expectedIDs  []string
+		expectedLine int64
+	}{
+		{name: "all", from: 0, to: -1, expectedIDs: []string{"0", "1", "2", "3", "4", "5", "6"}, expectedLine: 7},
+		{name: "first row group", from: 0, to: 3, expectedIDs: []string{"0", "1", "2"}, expectedLine: 3},
+		{name: "part of a row group", from: 4, to: 6, expectedIDs: []string{"4", "5"}, expectedLine: 6},
+		{name: "parts of row groups", from: 2, to: 5, expectedIDs: []string{"2", "3", "4"}, expectedLine: 5},
+		{name: "last", from: 6, to: 7, expectedIDs: []string{"6"}, expectedLine: 7},
+		{name: "none", from: 7, to: 7, expectedIDs: nil, expectedLine: 7},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := f.NewConverter(tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("failed to create converter: %v", err)
+			}
+			var ids []string
+			for _, item := range readAll(t, c) {
+				ids = append(ids, *item["id"].N)
+			}
+			if diff := cmp.Diff(tt.expectedIDs, ids); diff != "" {
+				t.Error(diff)
+			}
+			if c.Rows() != int64(len(tt.expectedIDs)) {
+				t.Errorf("expected %d rows, got %d", len(tt.expectedIDs), c.Rows())
+			}
+			if c.Line() != tt.expectedLine {
+				t.Errorf("expected line %d, got %d", tt.expectedLine, c.Line())
+			}
+		})
+	}
+	if _, err = f.NewConverter(5, 4); err == nil {
+		t.Error("expected an error for an invalid range")
+	}
+}
+
+func TestConverterInvalidNumbers(t *testing.T) {
+	for _, score := range []float64{math.NaN(), math.Inf(1), math.Inf(-1), 1e300, 1e-200} {
+		r := write(t, []row{{ID: 1, Score: score}}, 10)
+		f, err := Open(r, r.Size())
+		if err != nil {
+			t.Fatalf("failed to open: %v", err)
+		}
+		c, err := f.NewConverter(0, -1)
+		if err != nil {
+			t.Fatalf("failed to create converter: %v", err)
+		}
+		if _, err = c.Read(); err == nil {
+			t.Errorf("expected an error for a score of %v", score)
+		}
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	var tests = []struct {
+		input    []byte
+		scale    int
+		expected string
+	}{
+		{input: []byte{0x04, 0xd2}, scale: 2, expected: "12.34"},
+		{input: []byte{0xfb, 0x2e}, scale: 2, expected: "-12.34"},
+		{input: []byte{0x05}, scale: 3, expected: "0.005"},
+		{input: []byte{0xff}, scale: 0, expected: "-1"},
+	}
+	for _, tt := range tests {
+		if actual := decimal(twosComplement(tt.input), tt.scale); actual != tt.expected {
+			t.Errorf("%x with scale %d: expected %q, got %q", tt.input, tt.scale, tt.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package parquettodynamo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/xitongsys/parquet-go/source"
+)
+
+// readAhead is the minimum number of bytes read from the underlying reader, so that the footer isn't
+// read a few bytes at a time, e.g. using S3 range requests.
+const readAhead = 64 * 1024
+
+// readerAtFile implements the ParquetFile interface of the Parquet reader using an io.ReaderAt.
+type readerAtFile struct {
+	r         io.ReaderAt
+	size      int64
+	offset    int64
+	buf       []byte
+	bufOffset int64
+}
+
+func newReaderAtFile(r io.ReaderAt, size int64) *readerAtFile {
+	return &readerAtFile{r: r, size: size}
+}
+
+func (f *readerAtFile) Read(p []byte) (n int, err error) {
+	if f.offset >= f.size {
+		return 0, io.EOF
+	}
+	if remaining := f.size - f.offset; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	if len(p) >= readAhead {
+		n, err = f.r.ReadAt(p, f.offset)
+		f.offset += int64(n)
+		if err == io.EOF && n == len(p) {
+			err = nil
+		}
+		return
+	}
+	if f.offset < f.bufOffset || f.offset >= f.bufOffset+int64(len(f.buf)) {
+		if err = f.fill(); err != nil {
+			return
+		}
+	}
+	n = copy(p, f.buf[f.offset-f.bufOffset:])
+	f.offset += int64(n)
+	return
+}
+
+func (f *readerAtFile) fill() (err error) {
+	n := int64(readAhead)
+	if remaining := f.size - f.offset; n > remaining {
+		n = remaining
+	}
+	if cap(f.buf) < int(n) {
+		f.buf = make([]byte, n)
+	}
+	f.buf = f.buf[:n]
+	read, err := f.r.ReadAt(f.buf, f.offset)
+	f.buf, f.bufOffset = f.buf[:read], f.offset
+	if err == io.EOF && read > 0 {
+		err = nil
+	}
+	return
+}
+
+func (f *readerAtFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.size
+	}
+	if offset < 0 {
+		return f.offset, fmt.Errorf("parquettodynamo: invalid offset %d", offset)
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *readerAtFile) Write(p []byte) (n int, err error) {
+	return 0, errors.New("parquettodynamo: files are read only")
+}
+
+func (f *readerAtFile) Close() error {
+	return nil
+}
+
+// Open opens another handle to the file, so that columns can be read independently.
+func (f *readerAtFile) Open(name string) (source.ParquetFile, error) {
+	if name != "" {
+		return nil, fmt.Errorf("parquettodynamo: column chunks stored in other files, such as %q, are not supported", name)
+	}
+	return newReaderAtFile(f.r, f.size), nil
+}
+
+func (f *readerAtFile) Create(name string) (source.ParquetFile, error) {
+	return nil, errors.New("parquettodynamo: files are read only")
+}
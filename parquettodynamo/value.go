@@ -0,0 +1,419 @@
+package parquettodynamo
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/a-h/ddbimport/keys"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/google/uuid"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/schema"
+	"github.com/xitongsys/parquet-go/types"
+)
+
+// kind is how the values of a schema node are converted to DynamoDB attributes.
+type kind int
+
+const (
+	kindGroup kind = iota
+	kindList
+	kindLegacyList
+	kindMap
+	kindLegacyMap
+	kindBool
+	kindNumber
+	kindUnsigned
+	kindDecimal
+	kindString
+	kindBinary
+	kindDate
+	kindTime
+	kindTimestamp
+	kindInt96
+	kindUUID
+)
+
+// node of the Parquet schema.
+type node struct {
+	name string
+	// inName is the name of the node's field in the rows created by the Parquet reader.
+	inName   string
+	element  *parquet.SchemaElement
+	children []*node
+	kind     kind
+	// unit is the number of nanoseconds in each unit of a time or timestamp.
+	unit  int64
+	scale int
+	// element of a list, or key and value of a map.
+	item, key, value *node
+}
+
+// newNode creates the node at index i of the schema, and its children, incrementing i past them.
+func newNode(sh *schema.SchemaHandler, i *int) *node {
+	n := &node{
+		name:    sh.Infos[*i].ExName,
+		inName:  sh.Infos[*i].InName,
+		element: sh.SchemaElements[*i],
+	}
+	*i++
+	for c := int32(0); c < n.element.GetNumChildren(); c++ {
+		n.children = append(n.children, newNode(sh, i))
+	}
+	n.setKind()
+	return n
+}
+
+// setKind sets the kind of the node. Lists and maps are only converted to slices and maps by the
+// Parquet reader if they use the standard names, so the same rules are used here.
+func (n *node) setKind() {
+	e := n.element
+	lt := e.GetLogicalType()
+	if len(n.children) > 0 {
+		n.kind = kindGroup
+		isList := e.ConvertedType != nil && e.GetConvertedType() == parquet.ConvertedType_LIST
+		isMap := e.ConvertedType != nil && (e.GetConvertedType() == parquet.ConvertedType_MAP || e.GetConvertedType() == parquet.ConvertedType_MAP_KEY_VALUE)
+		switch {
+		case isList && len(n.children) == 1 && n.children[0].hasInNames("List", "Element"):
+			n.kind, n.item = kindList, n.children[0].children[0]
+		case isList && len(n.children) == 1:
+			n.kind = kindLegacyList
+		case isMap && e.GetConvertedType() == parquet.ConvertedType_MAP && len(n.children) == 1 && n.children[0].hasInNames("Key_value", "Key", "Value"):
+			n.kind, n.key, n.value = kindMap, n.children[0].children[0], n.children[0].children[1]
+		case isMap && len(n.children) == 1 && len(n.children[0].children) == 2:
+			n.kind, n.key, n.value = kindLegacyMap, n.children[0].children[0], n.children[0].children[1]
+		}
+		return
+	}
+	if e.ConvertedType != nil {
+		switch e.GetConvertedType() {
+		case parquet.ConvertedType_UTF8, parquet.ConvertedType_ENUM, parquet.ConvertedType_JSON:
+			n.kind = kindString
+			return
+		case parquet.ConvertedType_DECIMAL:
+			n.kind, n.scale = kindDecimal, int(e.GetScale())
+			return
+		case parquet.ConvertedType_DATE:
+			n.kind = kindDate
+			return
+		case parquet.ConvertedType_TIME_MILLIS:
+			n.kind, n.unit = kindTime, int64(time.Millisecond)
+			return
+		case parquet.ConvertedType_TIME_MICROS:
+			n.kind, n.unit = kindTime, int64(time.Microsecond)
+			return
+		case parquet.ConvertedType_TIMESTAMP_MILLIS:
+			n.kind, n.unit = kindTimestamp, int64(time.Millisecond)
+			return
+		case parquet.ConvertedType_TIMESTAMP_MICROS:
+			n.kind, n.unit = kindTimestamp, int64(time.Microsecond)
+			return
+		case parquet.ConvertedType_UINT_8, parquet.ConvertedType_UINT_16, parquet.ConvertedType_UINT_32, parquet.ConvertedType_UINT_64:
+			n.kind = kindUnsigned
+			return
+		}
+	}
+	if lt != nil {
+		switch {
+		case lt.STRING != nil, lt.ENUM != nil, lt.JSON != nil:
+			n.kind = kindString
+			return
+		case lt.DECIMAL != nil:
+			n.kind, n.scale = kindDecimal, int(lt.DECIMAL.Scale)
+			return
+		case lt.DATE != nil:
+			n.kind = kindDate
+			return
+		case lt.TIME != nil:
+			n.kind, n.unit = kindTime, unit(lt.TIME.Unit)
+			return
+		case lt.TIMESTAMP != nil:
+			n.kind, n.unit = kindTimestamp, unit(lt.TIMESTAMP.Unit)
+			return
+		case lt.INTEGER != nil && !lt.INTEGER.IsSigned:
+			n.kind = kindUnsigned
+			return
+		case lt.UUID != nil:
+			n.kind = kindUUID
+			return
+		}
+	}
+	switch e.GetType() {
+	case parquet.Type_BOOLEAN:
+		n.kind = kindBool
+	case parquet.Type_INT96:
+		n.kind = kindInt96
+	case parquet.Type_BYTE_ARRAY, parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		n.kind = kindBinary
+	default:
+		n.kind = kindNumber
+	}
+}
+
+// hasInNames returns true if the node has the field name, and a child with each of the child names.
+func (n *node) hasInNames(name string, children ...string) bool {
+	if n.inName != name || len(n.children) != len(children) {
+		return false
+	}
+	for i, c := range children {
+		if n.children[i].inName != c {
+			return false
+		}
+	}
+	return true
+}
+
+func unit(u *parquet.TimeUnit) int64 {
+	switch {
+	case u == nil:
+		return int64(time.Millisecond)
+	case u.MICROS != nil:
+		return int64(time.Microsecond)
+	case u.NANOS != nil:
+		return 1
+	}
+	return int64(time.Millisecond)
+}
+
+func (n *node) repeated() bool {
+	return n.element.RepetitionType != nil && n.element.GetRepetitionType() == parquet.FieldRepetitionType_REPEATED
+}
+
+// attributeType returns the DynamoDB type of the node's values.
+func (n *node) attributeType() string {
+	if n.repeated() {
+		return "L"
+	}
+	switch n.kind {
+	case kindGroup, kindMap, kindLegacyMap:
+		return "M"
+	case kindList, kindLegacyList:
+		return "L"
+	case kindBool:
+		return "BOOL"
+	case kindNumber, kindUnsigned, kindDecimal:
+		return dynamodb.ScalarAttributeTypeN
+	case kindBinary:
+		return dynamodb.ScalarAttributeTypeB
+	}
+	return dynamodb.ScalarAttributeTypeS
+}
+
+var nullValue = (&dynamodb.AttributeValue{}).SetNULL(true)
+
+// convert the value created by the Parquet reader for the node. Repeated nodes are converted to
+// lists. nil is returned for null values.
+func (n *node) convert(v reflect.Value) (*dynamodb.AttributeValue, error) {
+	if !n.repeated() {
+		return n.convertItem(v)
+	}
+	return list(v, n.convertItem)
+}
+
+// list converts a slice using the convert function for each item.
+func list(v reflect.Value, convert func(v reflect.Value) (*dynamodb.AttributeValue, error)) (*dynamodb.AttributeValue, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.IsNil() {
+		return nil, nil
+	}
+	l := make([]*dynamodb.AttributeValue, v.Len())
+	for i := range l {
+		av, err := convert(v.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		if av == nil {
+			// Nulls keep their position in the list.
+			av = nullValue
+		}
+		l[i] = av
+	}
+	return (&dynamodb.AttributeValue{}).SetL(l), nil
+}
+
+func (n *node) convertItem(v reflect.Value) (*dynamodb.AttributeValue, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	switch n.kind {
+	case kindGroup:
+		m := make(map[string]*dynamodb.AttributeValue, len(n.children))
+		for i, c := range n.children {
+			av, err := c.convert(v.Field(i))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", c.name, err)
+			}
+			if av != nil {
+				m[c.name] = av
+			}
+		}
+		return (&dynamodb.AttributeValue{}).SetM(m), nil
+	case kindList:
+		return list(v, n.item.convert)
+	case kindLegacyList:
+		return n.children[0].convert(v.Field(0))
+	case kindMap:
+		if v.IsNil() {
+			return nil, nil
+		}
+		m := make(map[string]*dynamodb.AttributeValue, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := n.entry(m, iter.Key(), iter.Value()); err != nil {
+				return nil, err
+			}
+		}
+		return (&dynamodb.AttributeValue{}).SetM(m), nil
+	case kindLegacyMap:
+		entries := v.Field(0)
+		m := make(map[string]*dynamodb.AttributeValue, entries.Len())
+		for i := 0; i < entries.Len(); i++ {
+			entry := entries.Index(i)
+			if err := n.entry(m, entry.Field(0), entry.Field(1)); err != nil {
+				return nil, err
+			}
+		}
+		return (&dynamodb.AttributeValue{}).SetM(m), nil
+	}
+	return n.scalar(v)
+}
+
+// entry converts the key and value of a map entry, and adds it to m unless the value is null.
+func (n *node) entry(m map[string]*dynamodb.AttributeValue, key, value reflect.Value) error {
+	k, err := n.key.convert(key)
+	if err != nil {
+		return err
+	}
+	av, err := n.value.convert(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", keyString(k), err)
+	}
+	if av != nil {
+		m[keyString(k)] = av
+	}
+	return nil
+}
+
+func (n *node) scalar(v reflect.Value) (*dynamodb.AttributeValue, error) {
+	switch v.Kind() {
+	case reflect.Bool:
+		return (&dynamodb.AttributeValue{}).SetBOOL(v.Bool()), nil
+	case reflect.Int32, reflect.Int64:
+		i := v.Int()
+		switch n.kind {
+		case kindUnsigned:
+			if v.Kind() == reflect.Int32 {
+				return number(strconv.FormatUint(uint64(uint32(i)), 10))
+			}
+			return number(strconv.FormatUint(uint64(i), 10))
+		case kindDecimal:
+			return number(decimal(big.NewInt(i), n.scale))
+		case kindDate:
+			return str(time.Unix(i*24*60*60, 0).UTC().Format("2006-01-02")), nil
+		case kindTime:
+			return str(time.Unix(0, i*n.unit).UTC().Format("15:04:05.999999999")), nil
+		case kindTimestamp:
+			perSecond := int64(time.Second) / n.unit
+			return str(time.Unix(i/perSecond, (i%perSecond)*n.unit).UTC().Format(time.RFC3339Nano)), nil
+		}
+		return number(strconv.FormatInt(i, 10))
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("%v can't be stored as a DynamoDB number", f)
+		}
+		bits := 64
+		if v.Kind() == reflect.Float32 {
+			bits = 32
+		}
+		return number(strconv.FormatFloat(f, 'f', -1, bits))
+	case reflect.String:
+		s := v.String()
+		switch n.kind {
+		case kindString:
+			return str(s), nil
+		case kindDecimal:
+			return number(decimal(twosComplement([]byte(s)), n.scale))
+		case kindInt96:
+			if len(s) != 12 {
+				return nullValue, nil
+			}
+			return str(types.INT96ToTime(s).Format(time.RFC3339Nano)), nil
+		case kindUUID:
+			id, err := uuid.FromBytes([]byte(s))
+			if err != nil {
+				return (&dynamodb.AttributeValue{}).SetB([]byte(s)), nil
+			}
+			return str(id.String()), nil
+		}
+		return (&dynamodb.AttributeValue{}).SetB([]byte(s)), nil
+	}
+	return str(fmt.Sprint(v.Interface())), nil
+}
+
+func str(s string) *dynamodb.AttributeValue {
+	return (&dynamodb.AttributeValue{}).SetS(s)
+}
+
+// number returns an error if DynamoDB can't store the number, e.g. because it has more than 38
+// significant digits.
+func number(s string) (*dynamodb.AttributeValue, error) {
+	if err := keys.ValidNumber(s); err != nil {
+		return nil, err
+	}
+	return (&dynamodb.AttributeValue{}).SetN(s), nil
+}
+
+// keyString returns the map key of an attribute, since the keys of DynamoDB maps are strings.
+func keyString(av *dynamodb.AttributeValue) string {
+	switch {
+	case av == nil:
+		return ""
+	case av.S != nil:
+		return *av.S
+	case av.N != nil:
+		return *av.N
+	case av.B != nil:
+		return string(av.B)
+	case av.BOOL != nil:
+		return strconv.FormatBool(*av.BOOL)
+	}
+	return av.String()
+}
+
+// decimal formats an unscaled decimal value, e.g. 1234 with a scale of 2 is 12.34.
+func decimal(unscaled *big.Int, scale int) string {
+	s := new(big.Int).Abs(unscaled).String()
+	if scale > 0 {
+		if len(s) <= scale {
+			s = strings.Repeat("0", scale-len(s)+1) + s
+		}
+		s = s[:len(s)-scale] + "." + s[len(s)-scale:]
+	}
+	if unscaled.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// twosComplement converts a big-endian two's complement integer to a big.Int.
+func twosComplement(b []byte) *big.Int {
+	i := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		i.Sub(i, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+	}
+	return i
+}
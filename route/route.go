@@ -0,0 +1,174 @@
+package route
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/a-h/ddbimport/batchwriter"
+	"github.com/a-h/ddbimport/dedupe"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Condition matches items where the column has the value.
+type Condition struct {
+	Column string
+	Value  string
+}
+
+// Rule routes matching items to a table.
+type Rule struct {
+	Table string
+	// Columns to write to the table. If empty, all columns are written.
+	Columns []string
+	// Conditions that must all match. If empty, all items match.
+	Conditions []Condition
+}
+
+// Parse a semicolon separated list of rules, each in the form table[:columns][?column=value&column=value],
+// e.g. "main;lookup:email,id?type=user".
+func Parse(s string) (rules []Rule, err error) {
+	for _, spec := range strings.Split(s, ";") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		var r Rule
+		if i := strings.Index(spec, "?"); i >= 0 {
+			for _, c := range strings.Split(spec[i+1:], "&") {
+				columnAndValue := strings.SplitN(c, "=", 2)
+				if len(columnAndValue) != 2 || columnAndValue[0] == "" {
+					err = fmt.Errorf("route: invalid condition %q in rule %q: expected column=value", c, spec)
+					return
+				}
+				r.Conditions = append(r.Conditions, Condition{Column: columnAndValue[0], Value: columnAndValue[1]})
+			}
+			spec = spec[:i]
+		}
+		if i := strings.Index(spec, ":"); i >= 0 {
+			r.Columns = strings.Split(spec[i+1:], ",")
+			spec = spec[:i]
+		}
+		if spec == "" {
+			err = fmt.Errorf("route: missing table name in rules %q", s)
+			return
+		}
+		r.Table = spec
+		rules = append(rules, r)
+	}
+	if len(rules) == 0 {
+		err = fmt.Errorf("route: no rules in %q", s)
+	}
+	return
+}
+
+// Matches returns true if the item matches all of the rule's conditions.
+// Numbers are compared as written in the file, and booleans are compared to "true" or "false".
+func (r Rule) Matches(item map[string]*dynamodb.AttributeValue) bool {
+	for _, c := range r.Conditions {
+		v, ok := item[c.Column]
+		if !ok {
+			return false
+		}
+		var s string
+		switch {
+		case v.S != nil:
+			s = *v.S
+		case v.N != nil:
+			s = *v.N
+		case v.BOOL != nil:
+			s = strconv.FormatBool(*v.BOOL)
+		}
+		if s != c.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// Project the item onto the rule's columns.
+func (r Rule) Project(item map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+	if len(r.Columns) == 0 {
+		return item
+	}
+	projected := make(map[string]*dynamodb.AttributeValue, len(r.Columns))
+	for _, c := range r.Columns {
+		if v, ok := item[c]; ok {
+			projected[c] = v
+		}
+	}
+	return projected
+}
+
+// Tables returns the names of the tables that the rules route to, in order of first use.
+func Tables(rules []Rule) (tables []string) {
+	seen := map[string]bool{}
+	for _, r := range rules {
+		if !seen[r.Table] {
+			seen[r.Table] = true
+			tables = append(tables, r.Table)
+		}
+	}
+	return
+}
+
+type tableWriter interface {
+	WriteTables(tableToRecords map[string][]map[string]*dynamodb.AttributeValue) error
+}
+
+// Writer writes each record to the tables of the rules that it matches.
+type Writer struct {
+	rules    []Rule
+	keyNames map[string][]string
+	w        tableWriter
+}
+
+// NewWriter creates a Writer which routes records to tables in the region. keyNames are the
+// names of the key attributes of each table, used to remove duplicates within each batch.
+func NewWriter(region string, rules []Rule, keyNames map[string][]string) (w Writer, err error) {
+	bw, err := batchwriter.New(region, rules[0].Table)
+	if err != nil {
+		return
+	}
+	return newWriter(bw, rules, keyNames), nil
+}
+
+func newWriter(w tableWriter, rules []Rule, keyNames map[string][]string) Writer {
+	return Writer{
+		rules:    rules,
+		keyNames: keyNames,
+		w:        w,
+	}
+}
+
+// Write the records to the tables of the matching rules.
+// Projections can result in duplicate keys within a batch, e.g. when writing to a lookup table,
+// so the last record with each key wins.
+func (w Writer) Write(records []map[string]*dynamodb.AttributeValue) error {
+	tableToRecords := map[string][]map[string]*dynamodb.AttributeValue{}
+	tableToKeyIndex := map[string]map[string]int{}
+	for _, record := range records {
+		for _, r := range w.rules {
+			if !r.Matches(record) {
+				continue
+			}
+			projected := r.Project(record)
+			k, ok := dedupe.Key(projected, w.keyNames[r.Table])
+			if !ok {
+				// Items without keys are left for DynamoDB to reject.
+				tableToRecords[r.Table] = append(tableToRecords[r.Table], projected)
+				continue
+			}
+			if tableToKeyIndex[r.Table] == nil {
+				tableToKeyIndex[r.Table] = map[string]int{}
+			}
+			if i, isDuplicate := tableToKeyIndex[r.Table][k]; isDuplicate {
+				tableToRecords[r.Table][i] = projected
+				continue
+			}
+			tableToKeyIndex[r.Table][k] = len(tableToRecords[r.Table])
+			tableToRecords[r.Table] = append(tableToRecords[r.Table], projected)
+		}
+	}
+	return w.w.WriteTables(tableToRecords)
+}
@@ -0,0 +1,116 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		input         string
+		expected      []Rule
+		expectedError string
+	}{
+		{
+			input:    "main",
+			expected: []Rule{{Table: "main"}},
+		},
+		{
+			input: "main; lookup:email,id?type=user&active=true",
+			expected: []Rule{
+				{Table: "main"},
+				{
+					Table:      "lookup",
+					Columns:    []string{"email", "id"},
+					Conditions: []Condition{{Column: "type", Value: "user"}, {Column: "active", Value: "true"}},
+				},
+			},
+		},
+		{
+			input:         "main?type",
+			expectedError: `route: invalid condition "type" in rule "main?type": expected column=value`,
+		},
+		{
+			input:         ":id",
+			expectedError: `route: missing table name in rules ":id"`,
+		},
+		{
+			input:         ";",
+			expectedError: `route: no rules in ";"`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.input, func(t *testing.T) {
+			actual, err := Parse(tt.input)
+			var actualError string
+			if err != nil {
+				actualError = err.Error()
+			}
+			if actualError != tt.expectedError {
+				t.Errorf("expected error %q, got %q", tt.expectedError, actualError)
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+type mockTableWriter struct {
+	written map[string][]map[string]*dynamodb.AttributeValue
+}
+
+func (m *mockTableWriter) WriteTables(tableToRecords map[string][]map[string]*dynamodb.AttributeValue) error {
+	m.written = tableToRecords
+	return nil
+}
+
+func TestWriter(t *testing.T) {
+	rules, err := Parse("main;lookup:email,id?type=user;admins?admin=true")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mtw := &mockTableWriter{}
+	w := newWriter(mtw, rules, map[string][]string{
+		"main":   {"id"},
+		"lookup": {"email"},
+		"admins": {"id"},
+	})
+	user := func(id, email, userType string, admin bool) map[string]*dynamodb.AttributeValue {
+		return map[string]*dynamodb.AttributeValue{
+			"id":    {N: aws.String(id)},
+			"email": {S: aws.String(email)},
+			"type":  {S: aws.String(userType)},
+			"admin": {BOOL: aws.Bool(admin)},
+		}
+	}
+	err = w.Write([]map[string]*dynamodb.AttributeValue{
+		user("1", "a@example.com", "user", false),
+		user("2", "b@example.com", "service", true),
+		// The email address moved to a new account, so the lookup is replaced.
+		user("3", "a@example.com", "user", false),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string][]map[string]*dynamodb.AttributeValue{
+		"main": {
+			user("1", "a@example.com", "user", false),
+			user("2", "b@example.com", "service", true),
+			user("3", "a@example.com", "user", false),
+		},
+		"lookup": {
+			{"id": {N: aws.String("3")}, "email": {S: aws.String("a@example.com")}},
+		},
+		"admins": {
+			user("2", "b@example.com", "service", true),
+		},
+	}
+	if diff := cmp.Diff(expected, mtw.written); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,87 @@
+package s3reader
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// Object is an S3 object that's read using byte range requests, so that parts of it can be read
+// without downloading the whole object.
+type Object struct {
+	client s3iface.S3API
+	bucket string
+	key    string
+	// Size of the object in bytes.
+	Size         int64
+	LastModified time.Time
+	ETag         string
+}
+
+// Open gets the size and version of the object.
+func Open(region, bucket, key string) (o Object, err error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(region),
+	})
+	if err != nil {
+		return
+	}
+	return open(s3.New(sess), bucket, key)
+}
+
+func open(client s3iface.S3API, bucket, key string) (o Object, err error) {
+	hoo, err := client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return
+	}
+	o = Object{
+		client:       client,
+		bucket:       bucket,
+		key:          key,
+		Size:         aws.Int64Value(hoo.ContentLength),
+		LastModified: aws.TimeValue(hoo.LastModified),
+		ETag:         aws.StringValue(hoo.ETag),
+	}
+	return
+}
+
+// OpenRange opens the bytes of the object from start (inclusive) to end (exclusive).
+func (o Object) OpenRange(start, end int64) (io.ReadCloser, error) {
+	goo, err := o.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(o.bucket),
+		Key:    aws.String(o.key),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", start, end-1)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return goo.Body, nil
+}
+
+// ReadAt implements io.ReaderAt.
+func (o Object) ReadAt(p []byte, off int64) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if off >= o.Size {
+		return 0, io.EOF
+	}
+	body, err := o.OpenRange(off, off+int64(len(p)))
+	if err != nil {
+		return
+	}
+	defer body.Close()
+	n, err = io.ReadFull(body, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return
+}
@@ -0,0 +1,65 @@
+package s3reader
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+type mockClient struct {
+	s3iface.S3API
+	content string
+}
+
+func (m mockClient) HeadObject(*s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+	return &s3.HeadObjectOutput{ContentLength: aws.Int64(int64(len(m.content))), ETag: aws.String(`"abc"`)}, nil
+}
+
+func (m mockClient) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	var start, end int
+	if _, err := fmt.Sscanf(aws.StringValue(input.Range), "bytes=%d-%d", &start, &end); err != nil {
+		return nil, err
+	}
+	if end >= len(m.content) {
+		end = len(m.content) - 1
+	}
+	return &s3.GetObjectOutput{Body: ioutil.NopCloser(strings.NewReader(m.content[start : end+1]))}, nil
+}
+
+func TestReadAt(t *testing.T) {
+	o, err := open(mockClient{content: "abcdef"}, "bucket", "key")
+	if err != nil {
+		t.Fatalf("failed to open: %v", err)
+	}
+	if o.Size != 6 || o.ETag != `"abc"` {
+		t.Errorf("unexpected size %d and ETag %q", o.Size, o.ETag)
+	}
+	var tests = []struct {
+		off           int64
+		n             int
+		expected      string
+		expectedError error
+	}{
+		{off: 0, n: 3, expected: "abc"},
+		{off: 4, n: 2, expected: "ef"},
+		{off: 4, n: 4, expected: "ef", expectedError: io.EOF},
+		{off: 6, n: 2, expected: "", expectedError: io.EOF},
+		{off: 2, n: 0, expected: ""},
+	}
+	for _, tt := range tests {
+		p := make([]byte, tt.n)
+		n, err := o.ReadAt(p, tt.off)
+		if err != tt.expectedError {
+			t.Errorf("%d bytes at %d: expected error %v, got %v", tt.n, tt.off, tt.expectedError, err)
+		}
+		if actual := string(p[:n]); actual != tt.expected {
+			t.Errorf("%d bytes at %d: expected %q, got %q", tt.n, tt.off, tt.expected, actual)
+		}
+	}
+}
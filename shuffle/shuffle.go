@@ -0,0 +1,122 @@
+package shuffle
+
+import (
+	"hash/fnv"
+	"io"
+
+	"github.com/a-h/ddbimport/keys"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// buckets is the number of groups that partition key hashes are divided into.
+const buckets = 256
+
+// ItemReader reads items, keeping track of the line number of the last item read.
+type ItemReader interface {
+	Read() (item map[string]*dynamodb.AttributeValue, err error)
+	Line() int64
+}
+
+type entry struct {
+	item map[string]*dynamodb.AttributeValue
+	line int64
+}
+
+// Reader buffers a window of items, and returns them interleaved by the hash of their partition key,
+// so that consecutive items are spread across partitions, even if the input is sorted by partition key.
+// Items with the same partition key are returned in the order they were read.
+type Reader struct {
+	r            ItemReader
+	partitionKey string
+	window       int
+	buckets      [buckets][]entry
+	// active is the list of buckets that contain items, in round-robin order.
+	active   []int
+	next     int
+	buffered int
+	line     int64
+	err      error
+}
+
+// New creates a Reader that shuffles items read from r, using a buffer of window items.
+func New(r ItemReader, partitionKey string, window int) *Reader {
+	if window < 1 {
+		window = 1
+	}
+	return &Reader{
+		r:            r,
+		partitionKey: partitionKey,
+		window:       window,
+	}
+}
+
+// Read the next item.
+func (r *Reader) Read() (item map[string]*dynamodb.AttributeValue, err error) {
+	for r.err == nil && r.buffered < r.window {
+		item, err = r.r.Read()
+		if err == io.EOF {
+			r.err = err
+			break
+		}
+		if err != nil {
+			// Return errors straight away, so that the line number is correct.
+			r.line = r.r.Line()
+			return
+		}
+		r.push(entry{item: item, line: r.r.Line()})
+	}
+	if r.buffered == 0 {
+		return nil, r.err
+	}
+	e := r.pop()
+	r.line = e.line
+	return e.item, nil
+}
+
+// Line returns the line number of the last item read.
+func (r *Reader) Line() int64 {
+	return r.line
+}
+
+func (r *Reader) push(e entry) {
+	b := bucket(e.item[r.partitionKey])
+	if len(r.buckets[b]) == 0 {
+		r.active = append(r.active, b)
+	}
+	r.buckets[b] = append(r.buckets[b], e)
+	r.buffered++
+}
+
+func (r *Reader) pop() (e entry) {
+	if r.next >= len(r.active) {
+		r.next = 0
+	}
+	b := r.active[r.next]
+	e = r.buckets[b][0]
+	r.buckets[b][0] = entry{}
+	r.buckets[b] = r.buckets[b][1:]
+	r.buffered--
+	if len(r.buckets[b]) > 0 {
+		r.next++
+		return
+	}
+	// Remove the empty bucket, the next bucket moves into its place.
+	r.buckets[b] = nil
+	r.active = append(r.active[:r.next], r.active[r.next+1:]...)
+	return
+}
+
+func bucket(v *dynamodb.AttributeValue) int {
+	h := fnv.New32a()
+	switch {
+	case v == nil:
+	case v.S != nil:
+		h.Write([]byte(*v.S))
+	case v.N != nil:
+		// DynamoDB considers 1 and 1.0 to be the same value.
+		h.Write([]byte(keys.NormalizeNumber(*v.N)))
+	case v.B != nil:
+		h.Write(v.B)
+	}
+	return int(h.Sum32() % buckets)
+}
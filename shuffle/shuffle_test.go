@@ -0,0 +1,119 @@
+package shuffle
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/google/go-cmp/cmp"
+)
+
+type mockReader struct {
+	items []map[string]*dynamodb.AttributeValue
+	errs  map[int]error
+	index int
+}
+
+func (m *mockReader) Read() (item map[string]*dynamodb.AttributeValue, err error) {
+	if m.index >= len(m.items) {
+		return nil, io.EOF
+	}
+	item, err = m.items[m.index], m.errs[m.index]
+	m.index++
+	return
+}
+
+func (m *mockReader) Line() int64 {
+	return int64(m.index)
+}
+
+func item(pk string, sk int) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"pk": {S: aws.String(pk)},
+		"sk": {N: aws.String(string(rune('0' + sk)))},
+	}
+}
+
+type result struct {
+	PK   string
+	SK   string
+	Line int64
+}
+
+func readAll(t *testing.T, r *Reader) (results []result) {
+	for {
+		item, err := r.Read()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		results = append(results, result{PK: *item["pk"].S, SK: *item["sk"].N, Line: r.Line()})
+	}
+}
+
+func TestReader(t *testing.T) {
+	sorted := []map[string]*dynamodb.AttributeValue{
+		item("a", 1), item("a", 2), item("a", 3),
+		item("b", 1), item("b", 2), item("b", 3),
+		item("c", 1), item("c", 2), item("c", 3),
+	}
+	t.Run("sorted items are interleaved by partition key", func(t *testing.T) {
+		actual := readAll(t, New(&mockReader{items: sorted}, "pk", 9))
+		expected := []result{
+			{PK: "a", SK: "1", Line: 1},
+			{PK: "b", SK: "1", Line: 4},
+			{PK: "c", SK: "1", Line: 7},
+			{PK: "a", SK: "2", Line: 2},
+			{PK: "b", SK: "2", Line: 5},
+			{PK: "c", SK: "2", Line: 8},
+			{PK: "a", SK: "3", Line: 3},
+			{PK: "b", SK: "3", Line: 6},
+			{PK: "c", SK: "3", Line: 9},
+		}
+		if diff := cmp.Diff(expected, actual); diff != "" {
+			t.Error(diff)
+		}
+	})
+	t.Run("a window of 1 doesn't change the order", func(t *testing.T) {
+		actual := readAll(t, New(&mockReader{items: sorted}, "pk", 1))
+		for i, r := range actual {
+			if r.Line != int64(i+1) {
+				t.Errorf("expected line %d at index %d, got %d", i+1, i, r.Line)
+			}
+		}
+		if len(actual) != len(sorted) {
+			t.Errorf("expected %d items, got %d", len(sorted), len(actual))
+		}
+	})
+	t.Run("all items are returned when the window is smaller than the input", func(t *testing.T) {
+		actual := readAll(t, New(&mockReader{items: sorted}, "pk", 4))
+		if len(actual) != len(sorted) {
+			t.Errorf("expected %d items, got %d", len(sorted), len(actual))
+		}
+		seen := map[result]bool{}
+		for _, r := range actual {
+			seen[r] = true
+		}
+		if len(seen) != len(sorted) {
+			t.Errorf("expected %d unique items, got %d", len(sorted), len(seen))
+		}
+	})
+	t.Run("errors are returned with their line number", func(t *testing.T) {
+		expectedErr := errors.New("invalid")
+		r := New(&mockReader{items: sorted, errs: map[int]error{2: expectedErr}}, "pk", 9)
+		_, err := r.Read()
+		if err != expectedErr {
+			t.Fatalf("expected error %v, got %v", expectedErr, err)
+		}
+		if r.Line() != 3 {
+			t.Errorf("expected line 3, got %d", r.Line())
+		}
+		if remaining := readAll(t, r); len(remaining) != len(sorted)-1 {
+			t.Errorf("expected %d remaining items, got %d", len(sorted)-1, len(remaining))
+		}
+	})
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/csv"
 	"fmt"
 	"io"
 	"sync"
@@ -10,9 +9,19 @@ import (
 	"time"
 
 	"github.com/a-h/ddbimport/batchwriter"
+	"github.com/a-h/ddbimport/charset"
 	"github.com/a-h/ddbimport/csvtodynamo"
+	"github.com/a-h/ddbimport/dedupe"
+	"github.com/a-h/ddbimport/fixedwidth"
+	"github.com/a-h/ddbimport/jsonltodynamo"
 	"github.com/a-h/ddbimport/log"
+	"github.com/a-h/ddbimport/parquettodynamo"
+	"github.com/a-h/ddbimport/s3reader"
+	"github.com/a-h/ddbimport/shuffle"
+	"github.com/a-h/ddbimport/sls/linereader"
 	"github.com/a-h/ddbimport/sls/state"
+	"github.com/a-h/ddbimport/table"
+	"github.com/a-h/ddbimport/textformat"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -21,13 +30,7 @@ import (
 	"go.uber.org/zap"
 )
 
-// Response from the Lambda.
-type Response struct {
-	ProcessedCount int64 `json:"processedCount"`
-	DurationMS     int64 `json:"durationMs"`
-}
-
-func Handler(ctx context.Context, req state.ImportInput) (resp Response, err error) {
+func Handler(ctx context.Context, req state.ImportInput) (resp state.ImportOutput, err error) {
 	logger := log.Default.With(zap.String("sourceRegion", req.Source.Region),
 		zap.String("sourceBucket", req.Source.Bucket),
 		zap.String("sourceKey", req.Source.Key),
@@ -38,7 +41,9 @@ func Handler(ctx context.Context, req state.ImportInput) (resp Response, err err
 	logger.Info("starting", zap.Strings("numericFields", req.Source.NumericFields),
 		zap.Strings("booleanFields", req.Source.BooleanFields),
 		zap.Strings("cols", req.Columns),
-		zap.String("delimiter", req.Source.Delimiter))
+		zap.String("delimiter", req.Source.Delimiter),
+		zap.String("format", req.Source.Format),
+		zap.String("mode", req.Configuration.Mode))
 
 	start := time.Now()
 	var duration time.Duration
@@ -50,30 +55,69 @@ func Handler(ctx context.Context, req state.ImportInput) (resp Response, err err
 	if req.Source.Delimiter == "" {
 		req.Source.Delimiter = ","
 	}
-
-	// Get the file from S3.
-	src, err := get(req.Source.Region, req.Source.Bucket, req.Source.Key, req.Range[0], req.Range[1]-1)
+	if req.Configuration.Mode == "" {
+		req.Configuration.Mode = batchwriter.ModePut
+	}
+	if req.Configuration.OnDuplicateKey == "" {
+		req.Configuration.OnDuplicateKey = string(dedupe.LastWins)
+	}
+	onDuplicateKey, err := dedupe.ParsePolicy(req.Configuration.OnDuplicateKey)
 	if err != nil {
-		resp.DurationMS = time.Now().Sub(start).Milliseconds()
+		logger.Error("invalid configuration", zap.Error(err))
+		return
+	}
+	cs, err := charset.Parse(req.Source.Encoding)
+	if err != nil {
+		logger.Error("invalid configuration", zap.Error(err))
+		return
+	}
+	dialect, err := req.Source.Dialect()
+	if err != nil {
+		logger.Error("invalid configuration", zap.Error(err))
+		return
+	}
+	var layout textformat.Layout
+	if req.Source.Format == state.FormatFixedWidth {
+		if layout, err = req.Source.FixedWidth(); err != nil {
+			logger.Error("invalid configuration", zap.Error(err))
+			return
+		}
+	}
+	schema, err := table.Describe(req.Target.Region, req.Target.TableName)
+	if err != nil {
+		logger.Error("failed to describe table", zap.Error(err))
 		return
 	}
 
-	// Parse the CSV data.
-	csvr := csv.NewReader(src)
-	csvr.Comma = rune(req.Source.Delimiter[0])
-	conf := csvtodynamo.NewConfiguration()
-	if req.Range[0] > 0 {
-		csvr.FieldsPerRecord = len(req.Columns)
-		conf.Columns = req.Columns
+	// Parse the data.
+	var itemReader dedupe.ItemReader
+	if req.Source.Format == state.FormatParquet {
+		itemReader, err = newParquetReader(req)
+	} else {
+		itemReader, err = newTextReader(req, cs, dialect, layout)
 	}
-	conf.AddNumberKeys(req.Source.NumericFields...)
-	conf.AddBoolKeys(req.Source.BooleanFields...)
-	reader, err := csvtodynamo.NewConverter(csvr, conf)
 	if err != nil {
-		logger.Error("failed to create CSV reader", zap.Error(err))
+		logger.Error("failed to read source", zap.Error(err))
+		resp.DurationMS = time.Now().Sub(start).Milliseconds()
 		return
 	}
-	bw, err := batchwriter.New(req.Target.Region, req.Target.TableName)
+	sizeFilter := csvtodynamo.NewSizeFilter(itemReader, func(line int64, size int) {
+		logger.Warn("rejected item larger than the maximum item size", zap.Int64("line", line), zap.Int("size", size))
+	})
+	var shuffled dedupe.ItemReader = sizeFilter
+	if req.Configuration.ShuffleWindow > 0 {
+		shuffled = shuffle.New(sizeFilter, schema.Keys[0].Name, req.Configuration.ShuffleWindow)
+	}
+	reader := dedupe.New(shuffled, schema.KeyNames(), onDuplicateKey)
+	if req.Source.Format != state.FormatParquet {
+		// The lines of text formats are relative to the start of the byte range.
+		reader.Offset = req.Range[0]
+	}
+	updateOptions := batchwriter.UpdateOptions{
+		AddFields:   req.Configuration.AddFields,
+		RemoveEmpty: req.Configuration.RemoveEmpty,
+	}
+	bw, err := batchwriter.NewForMode(req.Configuration.Mode, req.Target.Region, req.Target.TableName, schema.KeyNames(), req.Configuration.ItemConcurrency, updateOptions)
 	if err != nil {
 		logger.Error("failed to create batch writer", zap.Error(err))
 		return
@@ -146,13 +190,88 @@ fillJobQueue:
 		err = errors[0]
 		return
 	}
-	logger.Info("complete")
+	if cpw, ok := bw.(*batchwriter.ConditionalPutWriter); ok {
+		resp.SkippedCount = cpw.Skipped()
+		logger = logger.With(zap.Int64("inserted", cpw.Inserted()), zap.Int64("skipped", cpw.Skipped()))
+	}
+	stats := sizeFilter.Stats()
+	resp.RejectedCount = stats.Rejected
+	resp.MaxItemSize = stats.Max
+	resp.ItemCount = stats.Count
+	resp.ItemBytes = stats.Total
+	resp.ItemSizeHistogram = stats.Histogram()
+	logger.Info("complete",
+		zap.Int64("rejected", stats.Rejected),
+		zap.Int("maxItemSize", stats.Max),
+		zap.Int("p99ItemSize", stats.Percentile(99)),
+		zap.Float64("meanItemSize", stats.Mean()))
 
 	resp.ProcessedCount = recordCount
 	resp.DurationMS = time.Now().Sub(start).Milliseconds()
 	return
 }
 
+// newTextReader reads the CSV, JSON Lines or fixed-width data in the byte range of the request.
+func newTextReader(req state.ImportInput, cs charset.Charset, dialect textformat.Dialect, layout textformat.Layout) (itemReader dedupe.ItemReader, err error) {
+	// Get the file from S3.
+	src, err := get(req.Source.Region, req.Source.Bucket, req.Source.Key, req.Range[0], req.Range[1]-1)
+	if err != nil {
+		return
+	}
+
+	// The preflight splits the file on encoded new lines, so each range can be converted to UTF-8
+	// independently.
+	decoded := cs.NewReader(src)
+	if req.Source.Format == state.FormatJSONLines {
+		return jsonltodynamo.NewConverter(decoded), nil
+	}
+	if req.Range[0] == 0 && dialect.SkipLines > 0 {
+		lr := linereader.New(src, 0, 0, nil).WithCharset(cs)
+		if err = lr.Skip(dialect.SkipLines); err != nil {
+			return nil, fmt.Errorf("failed to skip lines: %w", err)
+		}
+		decoded = lr
+	}
+	conf := csvtodynamo.NewConfiguration()
+	var rr csvtodynamo.RecordReader
+	if req.Source.Format == state.FormatFixedWidth {
+		// Fixed-width files don't have a header.
+		rr = fixedwidth.NewReader(decoded, layout)
+		conf.Columns = layout.Columns()
+	} else {
+		csvr := dialect.NewReader(decoded)
+		// Only the first range of a file with a header row contains the header.
+		if req.Range[0] > 0 || len(req.Source.Columns) > 0 {
+			csvr.FieldsPerRecord = len(req.Columns)
+			conf.Columns = req.Columns
+		}
+		rr = csvr
+	}
+	conf.AddNumberKeys(req.Source.NumericFields...)
+	conf.AddBoolKeys(req.Source.BooleanFields...)
+	conf.EmptyAsNull = req.Configuration.Mode == batchwriter.ModeUpdate && req.Configuration.RemoveEmpty
+	return csvtodynamo.NewConverter(rr, conf)
+}
+
+// newParquetReader reads the rows in the range of the request. Parquet files are read using byte range
+// requests, since the row groups are located using the footer of the file.
+func newParquetReader(req state.ImportInput) (itemReader dedupe.ItemReader, err error) {
+	o, err := s3reader.Open(req.Source.Region, req.Source.Bucket, req.Source.Key)
+	if err != nil {
+		return
+	}
+	f, err := parquettodynamo.Open(o, o.Size)
+	if err != nil {
+		return
+	}
+	c, err := f.NewConverter(req.Range[0], req.Range[1])
+	if err != nil {
+		return
+	}
+	c.NullAttributes = req.Configuration.Mode == batchwriter.ModeUpdate && req.Configuration.RemoveEmpty
+	return c, nil
+}
+
 func get(region, bucket, key string, from, to int64) (io.ReadCloser, error) {
 	sess, err := session.NewSession(&aws.Config{
 		Region: aws.String(region),
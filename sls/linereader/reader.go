@@ -2,7 +2,11 @@ package linereader
 
 import (
 	"bufio"
+	"bytes"
 	"io"
+
+	"github.com/a-h/ddbimport/charset"
+	"github.com/a-h/ddbimport/textformat"
 )
 
 // New creates a new LineReader. A reader that keeps track of the line positions within the source.
@@ -15,6 +19,54 @@ func New(r io.Reader, startLine, startOffset int64, onNewLine func(line, offset
 	}
 }
 
+// NewCSV creates a new LineReader that only counts new lines at the end of CSV records, so that new
+// lines within quoted fields are not treated as the start of a new line.
+func NewCSV(r io.Reader, startLine, startOffset int64, onNewLine func(line, offset int64)) *LineReader {
+	lr := New(r, startLine, startOffset, onNewLine)
+	lr.quote = '"'
+	return lr
+}
+
+// WithCharset sets the character encoding of the source. Each line is converted to UTF-8, while the
+// offset remains the position within the source.
+func (lr *LineReader) WithCharset(c charset.Charset) *LineReader {
+	lr.charset = c
+	return lr
+}
+
+// WithDialect sets the CSV dialect of the source, so that quotes within comment lines are ignored. Lazy
+// quotes can't be tracked, so every new line is counted.
+func (lr *LineReader) WithDialect(d textformat.Dialect) *LineReader {
+	if d.Comment != 0 {
+		lr.comment = []byte(string(d.Comment))
+	}
+	if d.LazyQuotes {
+		lr.quote = 0
+	}
+	return lr
+}
+
+// Skip n lines, ignoring any quotes. It must be called before the first Read.
+func (lr *LineReader) Skip(n int) error {
+	for i := 0; i < n; i++ {
+		line, err := lr.charset.ReadLine(lr.r)
+		if len(line) > 0 {
+			lr.Line++
+			lr.Offset += int64(len(line))
+			if lr.onNewLine != nil {
+				lr.onNewLine(lr.Line, lr.Offset)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // LineReader keeps track of how many lines have been read.
 type LineReader struct {
 	r                     *bufio.Reader
@@ -25,6 +77,11 @@ type LineReader struct {
 	Offset                int64
 	onNewLine             func(line, pos int64)
 	d                     []byte
+	quote                 byte
+	inQuotes              bool
+	charset               charset.Charset
+	sourceBytes           int
+	comment               []byte
 }
 
 func (lr *LineReader) Read(p []byte) (n int, err error) {
@@ -34,7 +91,7 @@ func (lr *LineReader) Read(p []byte) (n int, err error) {
 	if len(lr.remainder) > 0 {
 		lr.d = lr.remainder
 	} else {
-		lr.d, err = lr.r.ReadBytes('\n')
+		lr.d, err = lr.charset.ReadLine(lr.r)
 		if err != nil {
 			if err != io.EOF {
 				return
@@ -42,6 +99,15 @@ func (lr *LineReader) Read(p []byte) (n int, err error) {
 			lr.eof = true
 			err = nil
 		}
+		lr.sourceBytes = len(lr.d)
+		if lr.d, err = lr.charset.Decode(lr.d); err != nil {
+			return
+		}
+		// Escaped quotes are written twice, so only an odd number of quotes changes the state.
+		isComment := !lr.inQuotes && len(lr.comment) > 0 && bytes.HasPrefix(lr.d, lr.comment)
+		if lr.quote != 0 && !isComment && bytes.Count(lr.d, []byte{lr.quote})%2 == 1 {
+			lr.inQuotes = !lr.inQuotes
+		}
 	}
 	n = len(lr.d)
 	if len(p) < n {
@@ -49,8 +115,12 @@ func (lr *LineReader) Read(p []byte) (n int, err error) {
 	}
 	copy(p, lr.d[0:n])
 	lr.remainder = lr.d[n:]
-	lr.bytesSinceLastNewLine += n
-	if len(lr.remainder) == 0 && lr.bytesSinceLastNewLine != 0 {
+	if len(lr.remainder) == 0 {
+		// Count the bytes of the line in the source, rather than the converted line.
+		lr.bytesSinceLastNewLine += lr.sourceBytes
+		lr.sourceBytes = 0
+	}
+	if len(lr.remainder) == 0 && lr.bytesSinceLastNewLine != 0 && !lr.inQuotes {
 		lr.Line++
 		lr.Offset += int64(lr.bytesSinceLastNewLine)
 		if lr.onNewLine != nil {
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math/rand"
 	"testing"
 
@@ -56,6 +57,53 @@ func TestLineReader(t *testing.T) {
 	}
 }
 
+func TestCSVLineReader(t *testing.T) {
+	var tests = []struct {
+		name     string
+		input    string
+		expected []int64
+	}{
+		{
+			name:     "unquoted records",
+			input:    "a,b\nc,d\n",
+			expected: []int64{4, 8},
+		},
+		{
+			name:     "new lines within quoted fields are part of the record",
+			input:    "a,\"b\nc\"\nd,e\n",
+			expected: []int64{8, 12},
+		},
+		{
+			name:     "escaped quotes don't end the quoted field",
+			input:    "a,\"b\"\"\nc\"\nd,\"\"\"e\"\"\"\n",
+			expected: []int64{10, 20},
+		},
+		{
+			name:     "fields with multiple new lines",
+			input:    "\"a\n\nb\"\nc\n",
+			expected: []int64{7, 9},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var actual []int64
+			lr := NewCSV(bytes.NewReader([]byte(tt.input)), 0, 0, func(line, offset int64) {
+				actual = append(actual, offset)
+			})
+			if _, err := ioutil.ReadAll(lr); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+			if lr.Line != int64(len(tt.expected)) {
+				t.Errorf("expected %d lines, got %d", len(tt.expected), lr.Line)
+			}
+		})
+	}
+}
+
 func generateLines(n, min, max int) ([]byte, []int64) {
 	var buf bytes.Buffer
 	var indices []int64
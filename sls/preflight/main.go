@@ -6,9 +6,14 @@ import (
 	"io"
 	"time"
 
+	"github.com/a-h/ddbimport/batchwriter"
+	"github.com/a-h/ddbimport/csvtodynamo"
 	"github.com/a-h/ddbimport/log"
+	"github.com/a-h/ddbimport/parquettodynamo"
+	"github.com/a-h/ddbimport/s3reader"
 	"github.com/a-h/ddbimport/sls/preflight/process"
 	"github.com/a-h/ddbimport/sls/state"
+	"github.com/a-h/ddbimport/table"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -24,7 +29,8 @@ func Handler(ctx context.Context, req state.State) (resp state.State, err error)
 		zap.String("tableName", req.Target.TableName))
 	logger.Info("starting", zap.Strings("numericFields", req.Source.NumericFields),
 		zap.Strings("booleanFields", req.Source.BooleanFields),
-		zap.String("delimiter", req.Source.Delimiter))
+		zap.String("delimiter", req.Source.Delimiter),
+		zap.String("format", req.Source.Format))
 
 	if req.Source.Delimiter == "" {
 		req.Source.Delimiter = ","
@@ -33,6 +39,36 @@ func Handler(ctx context.Context, req state.State) (resp state.State, err error)
 		req.Configuration.LambdaDurationSeconds = 300
 	}
 
+	// Allocate records to workers in batches of 100,000 lines.
+	// 100,000 lines / 25 BatchWriteOperations = 4000 operations per allocation.
+	// At 3000 records per second, each batch is 30 seconds of work.
+	var workerBatch int64 = 100000
+
+	if req.Source.Format == state.FormatParquet {
+		var f *parquettodynamo.File
+		if f, err = openParquet(req); err != nil {
+			return
+		}
+		resp = process.RowGroups(f.RowGroups(), f.Columns(), workerBatch, req)
+		err = validate(logger, resp, f.AttributeType)
+		return
+	}
+
+	if req.Configuration.Preflight == state.PreflightSample && req.Preflight.Offset == 0 && req.Preflight.Columns == nil {
+		resp, err = sample(logger, req)
+		if err == nil {
+			if req.Source.Format != state.FormatJSONLines {
+				err = validate(logger, resp, attributeType(resp.Source))
+			}
+			return
+		}
+		if err != process.ErrAmbiguousBoundary {
+			return
+		}
+		logger.Info("falling back to scanning the file")
+		resp, err = req, nil
+	}
+
 	// Get the file from S3.
 	src, srcSize, err := get(req.Source.Region, req.Source.Bucket, req.Source.Key, req.Preflight.Offset)
 	if err != nil {
@@ -40,16 +76,66 @@ func Handler(ctx context.Context, req state.State) (resp state.State, err error)
 	}
 	defer src.Close()
 
-	// Allocate records to workers in batches of 100,000 lines.
-	// 100,000 lines / 25 BatchWriteOperations = 4000 operations per allocation.
-	// At 3000 records per second, each batch is 30 seconds of work.
-	var workerBatch int64 = 100000
-
 	start := time.Now()
 	hasTimedOut := func() bool {
 		return time.Since(start) > req.Configuration.LambdaDurationSeconds*time.Second
 	}
-	return process.Process(logger, hasTimedOut, src, srcSize, workerBatch, req)
+	// Validate the columns against the table on the first run, as soon as they're known, so that the
+	// import fails fast.
+	var validateColumns func(s state.State) error
+	if req.Preflight.Columns == nil && req.Source.Format != state.FormatJSONLines {
+		validateColumns = func(s state.State) error {
+			return validate(logger, s, attributeType(s.Source))
+		}
+	}
+	return process.Process(logger, hasTimedOut, src, srcSize, workerBatch, req, validateColumns)
+}
+
+// Sampled batches are roughly 100,000 lines of 100 bytes, like the batches of a scan.
+const sampleBatchBytes = 10 * 1024 * 1024
+
+func sample(logger *zap.Logger, req state.State) (resp state.State, err error) {
+	o, err := s3reader.Open(req.Source.Region, req.Source.Bucket, req.Source.Key)
+	if err != nil {
+		return
+	}
+	return process.Sample(logger, o, o.Size, sampleBatchBytes, req)
+}
+
+// openParquet reads the footer of a Parquet file, which describes the schema and the row groups.
+func openParquet(req state.State) (f *parquettodynamo.File, err error) {
+	o, err := s3reader.Open(req.Source.Region, req.Source.Bucket, req.Source.Key)
+	if err != nil {
+		return
+	}
+	return parquettodynamo.Open(o, o.Size)
+}
+
+// attributeType returns the types of the fields of a text file, which are strings unless configured.
+func attributeType(src state.Source) func(name string) string {
+	conf := csvtodynamo.NewConfiguration()
+	conf.AddNumberKeys(src.NumericFields...)
+	conf.AddBoolKeys(src.BooleanFields...)
+	return conf.AttributeType
+}
+
+func validate(logger *zap.Logger, s state.State, attributeType func(name string) string) (err error) {
+	schema, err := table.Describe(s.Target.Region, s.Target.TableName)
+	if err != nil {
+		return
+	}
+	if s.Configuration.Mode == batchwriter.ModeDelete {
+		// Only the table keys are used to delete items.
+		schema.Indexes = nil
+	}
+	warnings, err := table.Validate(schema, s.Preflight.Columns, attributeType)
+	for _, w := range warnings {
+		logger.Warn(w)
+	}
+	if err != nil {
+		logger.Error("the file doesn't match the table", zap.Error(err))
+	}
+	return
 }
 
 func get(region, bucket, key string, startIndex int64) (io.ReadCloser, int64, error) {
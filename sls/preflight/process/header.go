@@ -0,0 +1,52 @@
+package process
+
+import (
+	"io"
+
+	"github.com/a-h/ddbimport/charset"
+	"github.com/a-h/ddbimport/csvtodynamo"
+	"github.com/a-h/ddbimport/sls/linereader"
+	"github.com/a-h/ddbimport/sls/state"
+)
+
+// Header reads the skipped lines and the header of the file, returning the columns and the offset of
+// the first record. CSV headers are read with the quote-aware line reader, so quoted header fields may
+// contain new lines. JSON lines files have no header, and the columns of fixed-width files are the
+// fields of the layout. If the columns of a CSV file are supplied, the file has no header row, so the
+// first record follows the skipped lines.
+func Header(r io.ReaderAt, size int64, src state.Source) (columns []string, end int64, err error) {
+	if src.Format == state.FormatJSONLines {
+		return nil, 0, nil
+	}
+	cs, err := charset.Parse(src.Encoding)
+	if err != nil {
+		return
+	}
+	dialect, err := src.Dialect()
+	if err != nil {
+		return
+	}
+	if src.Format == state.FormatFixedWidth {
+		layout, err := src.FixedWidth()
+		if err != nil {
+			return nil, 0, err
+		}
+		lr := linereader.New(io.NewSectionReader(r, 0, size), 0, 0, nil).WithCharset(cs)
+		if err = lr.Skip(dialect.SkipLines); err != nil {
+			return nil, 0, err
+		}
+		return layout.Columns(), lr.Offset, nil
+	}
+	lr := linereader.NewCSV(io.NewSectionReader(r, 0, size), 0, 0, nil).WithCharset(cs).WithDialect(dialect)
+	if err = lr.Skip(dialect.SkipLines); err != nil {
+		return
+	}
+	if len(src.Columns) > 0 {
+		return src.Columns, lr.Offset, nil
+	}
+	record, err := dialect.NewReader(lr).Read()
+	if err != nil && err != io.EOF {
+		return
+	}
+	return csvtodynamo.Header(record), lr.Offset, nil
+}
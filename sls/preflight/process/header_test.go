@@ -0,0 +1,74 @@
+package process
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/a-h/ddbimport/sls/state"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHeader(t *testing.T) {
+	var tests = []struct {
+		name            string
+		input           string
+		source          state.Source
+		expectedColumns []string
+		expectedEnd     int64
+	}{
+		{
+			name:            "CSV headers end at the first new line",
+			input:           "a,b\n1,2\n",
+			expectedColumns: []string{"a", "b"},
+			expectedEnd:     4,
+		},
+		{
+			name:            "quoted CSV header fields can contain new lines",
+			input:           "a,\"b\nc\"\n1,2\n",
+			expectedColumns: []string{"a", "b\nc"},
+			expectedEnd:     8,
+		},
+		{
+			name:            "skipped lines come before the header",
+			input:           "title\na,b\n1,2\n",
+			source:          state.Source{SkipLines: 1},
+			expectedColumns: []string{"a", "b"},
+			expectedEnd:     10,
+		},
+		{
+			name:            "supplied columns mean the file has no header row",
+			input:           "title\n1,2\n",
+			source:          state.Source{SkipLines: 1, Columns: []string{"a", "b"}},
+			expectedColumns: []string{"a", "b"},
+			expectedEnd:     6,
+		},
+		{
+			name:            "the columns of fixed-width files are the fields of the layout",
+			input:           "title\n0001Alice\n",
+			source:          state.Source{Format: state.FormatFixedWidth, Layout: "id:1:4,name:5:9", SkipLines: 1},
+			expectedColumns: []string{"id", "name"},
+			expectedEnd:     6,
+		},
+		{
+			name:        "JSON lines files have no header",
+			input:       "{}\n",
+			source:      state.Source{Format: state.FormatJSONLines},
+			expectedEnd: 0,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			columns, end, err := Header(strings.NewReader(tt.input), int64(len(tt.input)), tt.source)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expectedColumns, columns); diff != "" {
+				t.Error(diff)
+			}
+			if end != tt.expectedEnd {
+				t.Errorf("expected the header to end at %d, got %d", tt.expectedEnd, end)
+			}
+		})
+	}
+}
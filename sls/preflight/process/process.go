@@ -1,21 +1,36 @@
 package process
 
 import (
-	"encoding/csv"
+	"bufio"
 	"io"
 
+	"github.com/a-h/ddbimport/charset"
+	"github.com/a-h/ddbimport/csvtodynamo"
+	"github.com/a-h/ddbimport/fixedwidth"
 	"github.com/a-h/ddbimport/sls/linereader"
 	"github.com/a-h/ddbimport/sls/state"
+	"github.com/a-h/ddbimport/textformat"
 	"go.uber.org/zap"
 )
 
-func Process(logger *zap.Logger, hasTimedOut func() bool, src io.ReadCloser, srcSize int64, batchSize int64, req state.State) (resp state.State, err error) {
+// Process scans the file from the offset of the preflight, splitting it into batches of batchSize
+// records. If validate isn't nil, it's called once the columns are known, after the first record
+// is read, so that a file which doesn't match the table fails before the rest of the scan.
+func Process(logger *zap.Logger, hasTimedOut func() bool, src io.ReadCloser, srcSize int64, batchSize int64, req state.State, validate func(s state.State) error) (resp state.State, err error) {
 	resp = req
 
 	// Parse the CSV data, keeping track of the byte position in the file.
 	lines := resp.Preflight.Line
 	batchStartIndex := req.Preflight.Offset
-	lr := linereader.New(src, resp.Preflight.Line, resp.Preflight.Offset, func(line, offset int64) {
+	cs, err := charset.Parse(resp.Source.Encoding)
+	if err != nil {
+		return
+	}
+	dialect, err := resp.Source.Dialect()
+	if err != nil {
+		return
+	}
+	onNewLine := func(line, offset int64) {
 		lines++
 		resp.Preflight.Line = line
 		resp.Preflight.Offset = offset
@@ -23,14 +38,51 @@ func Process(logger *zap.Logger, hasTimedOut func() bool, src io.ReadCloser, src
 			resp.Batches = append(resp.Batches, []int64{batchStartIndex, offset})
 			batchStartIndex = offset
 		}
-	})
+	}
 
-	csvr := csv.NewReader(lr)
-	csvr.Comma = rune(resp.Source.Delimiter[0])
+	var lr *linereader.LineReader
+	var rr recordReader
+	switch resp.Source.Format {
+	case state.FormatJSONLines:
+		lr = linereader.New(src, resp.Preflight.Line, resp.Preflight.Offset, onNewLine).WithCharset(cs)
+		rr = lineRecordReader{r: bufio.NewReader(lr)}
+		// JSON Lines files don't have a header.
+		resp.Preflight.Columns = []string{}
+	case state.FormatFixedWidth:
+		var layout textformat.Layout
+		if layout, err = resp.Source.FixedWidth(); err != nil {
+			return
+		}
+		lr = linereader.New(src, resp.Preflight.Line, resp.Preflight.Offset, onNewLine).WithCharset(cs)
+		if req.Preflight.Offset == 0 {
+			if err = lr.Skip(dialect.SkipLines); err != nil {
+				return
+			}
+		}
+		rr = fixedwidth.NewReader(lr, layout)
+		// Fixed-width files don't have a header, the columns are the fields of the layout.
+		resp.Preflight.Columns = layout.Columns()
+	default:
+		// Batches must end at the end of a record, not at a new line within a quoted field.
+		lr = linereader.NewCSV(src, resp.Preflight.Line, resp.Preflight.Offset, onNewLine).WithCharset(cs).WithDialect(dialect)
+		if req.Preflight.Offset == 0 {
+			// The skipped lines are part of the first batch, which also contains the header.
+			if err = lr.Skip(dialect.SkipLines); err != nil {
+				return
+			}
+		}
+		csvr := dialect.NewReader(lr)
+		if len(resp.Source.Columns) > 0 {
+			// The file has no header row, so the first record is data.
+			resp.Preflight.Columns = resp.Source.Columns
+			csvr.FieldsPerRecord = len(resp.Source.Columns)
+		}
+		rr = csvr
+	}
 	var recordCount int64
 	for {
 		var record []string
-		record, err = csvr.Read()
+		record, err = rr.Read()
 		if err != nil && err != io.EOF {
 			return
 		}
@@ -39,7 +91,12 @@ func Process(logger *zap.Logger, hasTimedOut func() bool, src io.ReadCloser, src
 			logger.Info("progress update", zap.Int64("records", recordCount))
 		}
 		if resp.Preflight.Columns == nil {
-			resp.Preflight.Columns = record
+			resp.Preflight.Columns = csvtodynamo.Header(record)
+		}
+		if recordCount == 1 && validate != nil {
+			if err = validate(resp); err != nil {
+				return
+			}
 		}
 		if err == io.EOF {
 			// Add trailing records.
@@ -60,3 +117,20 @@ func Process(logger *zap.Logger, hasTimedOut func() bool, src io.ReadCloser, src
 		}
 	}
 }
+
+type recordReader interface {
+	Read() (record []string, err error)
+}
+
+// lineRecordReader reads each line as a single field record.
+type lineRecordReader struct {
+	r *bufio.Reader
+}
+
+func (lrr lineRecordReader) Read() (record []string, err error) {
+	line, err := lrr.r.ReadString('\n')
+	if err == io.EOF && len(line) > 0 {
+		err = nil
+	}
+	return []string{line}, err
+}
@@ -1,11 +1,14 @@
 package process
 
 import (
+	"encoding/csv"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"strings"
 	"testing"
 
+	"github.com/a-h/ddbimport/charset"
 	"github.com/a-h/ddbimport/sls/state"
 	"github.com/google/go-cmp/cmp"
 	"go.uber.org/zap"
@@ -60,7 +63,7 @@ func TestProcess(t *testing.T) {
 			req.Source.Delimiter = ","
 			req.Configuration.LambdaDurationSeconds = 500
 			hasTimedOut := func() bool { return false }
-			resp, err := Process(zap.New(nil), hasTimedOut, rdr, int64(size), tt.batchSize, req)
+			resp, err := Process(zap.New(nil), hasTimedOut, rdr, int64(size), tt.batchSize, req, nil)
 			if err != nil {
 				t.Error(err)
 				return
@@ -140,7 +143,7 @@ func TestProcessTimeout(t *testing.T) {
 				rowCount++
 				return rowCount >= tt.timeOutAfterNRows
 			}
-			resp, err := Process(zap.New(nil), hasTimedOut, rdr, int64(size), tt.batchSize, req)
+			resp, err := Process(zap.New(nil), hasTimedOut, rdr, int64(size), tt.batchSize, req, nil)
 			if err != nil {
 				t.Error(err)
 				return
@@ -158,3 +161,214 @@ func TestProcessTimeout(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessJSONLines(t *testing.T) {
+	src := strings.Repeat(`{"a":"x"}`+"\n", 5)
+	rdr := ioutil.NopCloser(strings.NewReader(src))
+	var req state.State
+	req.Source.Delimiter = ","
+	req.Source.Format = state.FormatJSONLines
+	hasTimedOut := func() bool { return false }
+	resp, err := Process(zap.New(nil), hasTimedOut, rdr, int64(len(src)), 2, req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedBatches := [][]int64{
+		{0, 20},
+		{20, 40},
+		{40, 50},
+	}
+	if diff := cmp.Diff(expectedBatches, resp.Batches); diff != "" {
+		t.Error(diff)
+	}
+	if len(resp.Preflight.Columns) != 0 {
+		t.Errorf("expected no columns, got %v", resp.Preflight.Columns)
+	}
+}
+
+func TestProcessQuotedNewLines(t *testing.T) {
+	// Each row is 12 bytes, and contains a new line and an escaped quote within a quoted field.
+	src := "a,b,c\n" + strings.Repeat("x,\"y\n\"\"z\",w\n", 4)
+	rdr := ioutil.NopCloser(strings.NewReader(src))
+	var req state.State
+	req.Source.Delimiter = ","
+	hasTimedOut := func() bool { return false }
+	resp, err := Process(zap.New(nil), hasTimedOut, rdr, int64(len(src)), 2, req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedBatches := [][]int64{
+		{0, 18},  // Header and first row.
+		{18, 42}, // Second and third rows.
+		{42, 54}, // Remainder.
+	}
+	if diff := cmp.Diff(expectedBatches, resp.Batches); diff != "" {
+		t.Error(diff)
+	}
+	// Each batch after the first must start at the beginning of a record.
+	for _, b := range resp.Batches[1:] {
+		records, err := csv.NewReader(strings.NewReader(src[b[0]:b[1]])).ReadAll()
+		if err != nil {
+			t.Errorf("failed to parse batch %v: %v", b, err)
+			continue
+		}
+		for _, r := range records {
+			if diff := cmp.Diff([]string{"x", "y\n\"z", "w"}, r); diff != "" {
+				t.Errorf("batch %v: %s", b, diff)
+			}
+		}
+	}
+}
+
+func TestProcessWindowsFiles(t *testing.T) {
+	// A 3 byte byte order mark, and rows ending in \r\n.
+	src := "\ufeffa,b,c\r\n" + strings.Repeat("x,y,z\r\n", 3)
+	rdr := ioutil.NopCloser(strings.NewReader(src))
+	var req state.State
+	req.Source.Delimiter = ","
+	hasTimedOut := func() bool { return false }
+	resp, err := Process(zap.New(nil), hasTimedOut, rdr, int64(len(src)), 2, req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedBatches := [][]int64{
+		{0, 17},  // Byte order mark, header and first row.
+		{17, 31}, // Remainder.
+	}
+	if diff := cmp.Diff(expectedBatches, resp.Batches); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff([]string{"a", "b", "c"}, resp.Preflight.Columns); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestProcessUTF16(t *testing.T) {
+	// Each character is 2 bytes, including the byte order mark, so each row is 12 bytes.
+	src := utf16LE("\ufeffa,b,c\n" + strings.Repeat("x,\u0a0d,z\n", 3))
+	rdr := ioutil.NopCloser(strings.NewReader(src))
+	var req state.State
+	req.Source.Delimiter = ","
+	req.Source.Encoding = charset.UTF16LE
+	hasTimedOut := func() bool { return false }
+	resp, err := Process(zap.New(nil), hasTimedOut, rdr, int64(len(src)), 2, req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedBatches := [][]int64{
+		{0, 26},  // Byte order mark, header and first row.
+		{26, 50}, // Remainder.
+	}
+	if diff := cmp.Diff(expectedBatches, resp.Batches); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff([]string{"a", "b", "c"}, resp.Preflight.Columns); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func utf16LE(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		sb.WriteByte(byte(r))
+		sb.WriteByte(byte(r >> 8))
+	}
+	return sb.String()
+}
+
+func TestProcessDialect(t *testing.T) {
+	// A title line to skip, and a comment with an unmatched quote that must not change the batches.
+	src := "Exported \"users\n" + "a|b|c\n" + "# it's a \"comment\n" + strings.Repeat("x|y|z\n", 3)
+	rdr := ioutil.NopCloser(strings.NewReader(src))
+	var req state.State
+	req.Source.Delimiter = "pipe"
+	req.Source.Comment = "#"
+	req.Source.SkipLines = 1
+	hasTimedOut := func() bool { return false }
+	resp, err := Process(zap.New(nil), hasTimedOut, rdr, int64(len(src)), 2, req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedBatches := [][]int64{
+		{0, 22},  // Title and header.
+		{22, 46}, // Comment and first row.
+		{46, 58}, // Remainder.
+	}
+	if diff := cmp.Diff(expectedBatches, resp.Batches); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff([]string{"a", "b", "c"}, resp.Preflight.Columns); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestProcessColumns(t *testing.T) {
+	// Files without a header row start with data.
+	src := strings.Repeat("x,y,z\n", 3)
+	rdr := ioutil.NopCloser(strings.NewReader(src))
+	var req state.State
+	req.Source.Columns = []string{"a", "b", "c"}
+	hasTimedOut := func() bool { return false }
+	resp, err := Process(zap.New(nil), hasTimedOut, rdr, int64(len(src)), 2, req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedBatches := [][]int64{
+		{0, 12},
+		{12, 18},
+	}
+	if diff := cmp.Diff(expectedBatches, resp.Batches); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff([]string{"a", "b", "c"}, resp.Preflight.Columns); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestProcessFixedWidth(t *testing.T) {
+	// A title line to skip, followed by records without a header.
+	src := "USERS\n" + strings.Repeat("0001Alice\n", 3)
+	rdr := ioutil.NopCloser(strings.NewReader(src))
+	var req state.State
+	req.Source.Format = state.FormatFixedWidth
+	req.Source.Layout = "id:1:4,name:5:5"
+	req.Source.SkipLines = 1
+	hasTimedOut := func() bool { return false }
+	resp, err := Process(zap.New(nil), hasTimedOut, rdr, int64(len(src)), 2, req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedBatches := [][]int64{
+		{0, 16},  // Title and first row.
+		{16, 36}, // Remainder.
+	}
+	if diff := cmp.Diff(expectedBatches, resp.Batches); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff([]string{"id", "name"}, resp.Preflight.Columns); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestProcessValidatesBeforeScanning(t *testing.T) {
+	src := "a,b,c\n" + strings.Repeat("x,y,z\n", 10)
+	rdr := ioutil.NopCloser(strings.NewReader(src))
+	var req state.State
+	hasTimedOut := func() bool { return false }
+	errMismatch := errors.New("mismatch")
+	var validated []string
+	validate := func(s state.State) error {
+		validated = s.Preflight.Columns
+		return errMismatch
+	}
+	resp, err := Process(zap.New(nil), hasTimedOut, rdr, int64(len(src)), 2, req, validate)
+	if err != errMismatch {
+		t.Fatalf("expected the validation error, got %v", err)
+	}
+	if diff := cmp.Diff([]string{"a", "b", "c"}, validated); diff != "" {
+		t.Error(diff)
+	}
+	if len(resp.Batches) != 0 {
+		t.Errorf("expected the scan to stop before any batches, got %v", resp.Batches)
+	}
+}
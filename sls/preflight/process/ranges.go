@@ -0,0 +1,75 @@
+package process
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/a-h/ddbimport/charset"
+	"github.com/a-h/ddbimport/sls/linereader"
+	"github.com/a-h/ddbimport/sls/state"
+)
+
+// Ranges splits the records of a file, from headerEnd to size, into up to n ranges of a similar size
+// that start at the beginning of a record, so that they can be read in parallel. Each range is a start
+// offset (inclusive) and end offset (exclusive), like the batches of Sample. The boundaries are found
+// by reading around evenly spaced offsets like Sample, and if a boundary is ambiguous, by reading the
+// file from the header, keeping track of quoted fields. columns is the number of fields of each CSV
+// record.
+func Ranges(r io.ReaderAt, size, headerEnd int64, n int, src state.Source, columns int) (ranges [][]int64, err error) {
+	if headerEnd >= size {
+		return nil, nil
+	}
+	cs, err := charset.Parse(src.Encoding)
+	if err != nil {
+		return
+	}
+	dialect, err := src.Dialect()
+	if err != nil {
+		return
+	}
+	sp := splitter{r: r, size: size, headerEnd: headerEnd, cs: cs, dialect: dialect, columns: columns}
+	if src.Format != state.FormatJSONLines && src.Format != state.FormatFixedWidth {
+		sp.quote = '"'
+	}
+	if n < 1 {
+		n = 1
+	}
+	offsets := make([]int64, n-1)
+	for i := range offsets {
+		offsets[i] = headerEnd + (size-headerEnd)*int64(i+1)/int64(n)
+	}
+	ranges, err = sp.sample(headerEnd, offsets)
+	if err == ErrAmbiguousBoundary {
+		return sp.scan(headerEnd, offsets)
+	}
+	return
+}
+
+// scan divides the file from start into ranges that end at the start of the first record after each
+// offset, by reading the file sequentially.
+func (sp splitter) scan(start int64, offsets []int64) (ranges [][]int64, err error) {
+	onNewLine := func(line, offset int64) {
+		if len(offsets) == 0 || offset < offsets[0] {
+			return
+		}
+		for len(offsets) > 0 && offsets[0] <= offset {
+			offsets = offsets[1:]
+		}
+		if offset > start && offset < sp.size {
+			ranges = append(ranges, []int64{start, offset})
+			start = offset
+		}
+	}
+	section := io.NewSectionReader(sp.r, start, sp.size-start)
+	lr := linereader.New(section, 0, start, onNewLine)
+	if sp.quote != 0 {
+		lr = linereader.NewCSV(section, 0, start, onNewLine).WithDialect(sp.dialect)
+	}
+	if _, err = io.Copy(ioutil.Discard, lr.WithCharset(sp.cs)); err != nil {
+		return nil, err
+	}
+	if start < sp.size {
+		ranges = append(ranges, []int64{start, sp.size})
+	}
+	return
+}
@@ -0,0 +1,99 @@
+package process
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/a-h/ddbimport/sls/state"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRanges(t *testing.T) {
+	var tests = []struct {
+		name      string
+		input     string
+		format    string
+		headerEnd int64
+		columns   int
+		n         int
+		expected  [][]int64
+	}{
+		{
+			name:     "a single range covers the whole file",
+			input:    "a\nb\nc\n",
+			format:   state.FormatJSONLines,
+			n:        1,
+			expected: [][]int64{{0, 6}},
+		},
+		{
+			name:     "ranges start at the beginning of a line",
+			input:    "aaa\nbbb\nccc\nddd\n",
+			format:   state.FormatJSONLines,
+			n:        2,
+			expected: [][]int64{{0, 8}, {8, 16}},
+		},
+		{
+			name:     "split points within a line move to the next line",
+			input:    "aaaaaaa\nb\nc\n",
+			format:   state.FormatJSONLines,
+			n:        2,
+			expected: [][]int64{{0, 8}, {8, 12}},
+		},
+		{
+			name:     "long lines result in fewer ranges",
+			input:    "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa\nb\n",
+			format:   state.FormatJSONLines,
+			n:        4,
+			expected: [][]int64{{0, 58}, {58, 60}},
+		},
+		{
+			name:     "files without a trailing new line are included",
+			input:    "aaa\nbbb\nccc",
+			format:   state.FormatJSONLines,
+			n:        3,
+			expected: [][]int64{{0, 4}, {4, 8}, {8, 11}},
+		},
+		{
+			name:   "empty files have no ranges",
+			input:  "",
+			format: state.FormatJSONLines,
+			n:      4,
+		},
+		{
+			name:      "ranges start after the header",
+			input:     "a,b,c\nx,y,z\nx,y,z\nx,y,z\nx,y,z\n",
+			headerEnd: 6,
+			columns:   3,
+			n:         2,
+			expected:  [][]int64{{6, 18}, {18, 30}},
+		},
+		{
+			name:      "files with only a header have no ranges",
+			input:     "a,b,c\n",
+			headerEnd: 6,
+			columns:   3,
+			n:         2,
+		},
+		{
+			name:      "new lines within quoted fields don't start a range",
+			input:     "a,b,c\n" + "x,\"y\nA,B,C\nD,E,F\nz\",w\n" + "x,y,z\n",
+			headerEnd: 6,
+			columns:   3,
+			n:         3,
+			expected:  [][]int64{{6, 28}, {28, 34}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			src := state.Source{Delimiter: ",", Format: tt.format}
+			actual, err := Ranges(strings.NewReader(tt.input), int64(len(tt.input)), tt.headerEnd, tt.n, src, tt.columns)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
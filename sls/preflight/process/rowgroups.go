@@ -0,0 +1,40 @@
+package process
+
+import (
+	"github.com/a-h/ddbimport/sls/state"
+)
+
+// RowGroups allocates the rows of a Parquet file to batches of at least batchSize rows. Each batch
+// is a range of rows (from, to). Small row groups are combined, so that batches end at the end of a
+// row group, while row groups larger than batchSize are split into batches of similar sizes.
+func RowGroups(rows []int64, columns []string, batchSize int64, req state.State) (resp state.State) {
+	resp = req
+	resp.Preflight.Columns = columns
+	var batchStart, offset int64
+	for _, n := range rows {
+		resp.Preflight.Line += n
+		if n > batchSize {
+			if batchStart < offset {
+				resp.Batches = append(resp.Batches, []int64{batchStart, offset})
+			}
+			parts := (n + batchSize - 1) / batchSize
+			for i := int64(0); i < parts; i++ {
+				resp.Batches = append(resp.Batches, []int64{offset + n*i/parts, offset + n*(i+1)/parts})
+			}
+			offset += n
+			batchStart = offset
+			continue
+		}
+		offset += n
+		if offset-batchStart >= batchSize {
+			resp.Batches = append(resp.Batches, []int64{batchStart, offset})
+			batchStart = offset
+		}
+	}
+	if batchStart < offset {
+		resp.Batches = append(resp.Batches, []int64{batchStart, offset})
+	}
+	resp.Preflight.Offset = offset
+	resp.Preflight.Continue = false
+	return
+}
@@ -0,0 +1,52 @@
+package process
+
+import (
+	"testing"
+
+	"github.com/a-h/ddbimport/sls/state"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRowGroups(t *testing.T) {
+	var tests = []struct {
+		name            string
+		rows            []int64
+		batchSize       int64
+		expectedBatches [][]int64
+	}{
+		{
+			name:            "no row groups",
+			rows:            nil,
+			batchSize:       10,
+			expectedBatches: nil,
+		},
+		{
+			name:            "small row groups are combined",
+			rows:            []int64{4, 4, 4, 4, 4},
+			batchSize:       10,
+			expectedBatches: [][]int64{{0, 12}, {12, 20}},
+		},
+		{
+			name:            "large row groups are split by row",
+			rows:            []int64{25, 5, 30},
+			batchSize:       10,
+			expectedBatches: [][]int64{{0, 8}, {8, 16}, {16, 25}, {25, 30}, {30, 40}, {40, 50}, {50, 60}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var req state.State
+			resp := RowGroups(tt.rows, []string{"id"}, tt.batchSize, req)
+			if diff := cmp.Diff(tt.expectedBatches, resp.Batches); diff != "" {
+				t.Error(diff)
+			}
+			if resp.Preflight.Continue {
+				t.Error("expected the preflight to complete")
+			}
+			if diff := cmp.Diff([]string{"id"}, resp.Preflight.Columns); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package process
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/a-h/ddbimport/charset"
+	"github.com/a-h/ddbimport/sls/state"
+	"github.com/a-h/ddbimport/textformat"
+	"go.uber.org/zap"
+)
+
+// ErrAmbiguousBoundary is returned by Sample when the start of a record can't be found without reading
+// the file from the start, e.g. because quoted fields may contain new lines.
+var ErrAmbiguousBoundary = errors.New("process: record boundary is ambiguous")
+
+// sampleWindow is the number of bytes read around each offset to find the start of the next record.
+const sampleWindow = 64 * 1024
+
+// Sample divides the file into batches of roughly batchBytes, by reading around evenly spaced offsets
+// to find the start of the next record, instead of reading the whole file. Batches end at the end of
+// a line, so if the CSV data read before an offset contains quotes, or the line after it doesn't have
+// the same number of fields as the header, ErrAmbiguousBoundary is returned and the whole file must be
+// processed instead. Files encoded with 2 bytes per new line are always processed.
+func Sample(logger *zap.Logger, r io.ReaderAt, size, batchBytes int64, req state.State) (resp state.State, err error) {
+	resp = req
+	resp.Batches = nil
+	cs, err := charset.Parse(resp.Source.Encoding)
+	if err != nil {
+		return
+	}
+	if cs.Wide() {
+		return resp, ErrAmbiguousBoundary
+	}
+
+	dialect, err := resp.Source.Dialect()
+	if err != nil {
+		return
+	}
+
+	var quote byte
+	if resp.Source.Format != state.FormatJSONLines && resp.Source.Format != state.FormatFixedWidth {
+		quote = '"'
+	}
+	columns, headerEnd, err := Header(r, size, resp.Source)
+	if err != nil {
+		return
+	}
+	if columns == nil {
+		columns = []string{}
+	}
+	resp.Preflight.Columns = columns
+
+	var offsets []int64
+	for offset := headerEnd + batchBytes; offset < size; offset += batchBytes {
+		offsets = append(offsets, offset)
+	}
+	sp := splitter{r: r, size: size, headerEnd: headerEnd, cs: cs, dialect: dialect, quote: quote, columns: len(resp.Preflight.Columns)}
+	resp.Batches, err = sp.sample(0, offsets)
+	if err != nil {
+		logger.Info("sampling failed", zap.Error(err))
+		return
+	}
+	if len(resp.Batches) == 0 {
+		resp.Batches = [][]int64{{0, size}}
+	}
+	resp.Preflight.Offset = size
+	resp.Preflight.Continue = false
+	logger.Info("complete", zap.Int("batches", len(resp.Batches)))
+	return
+}
+
+// splitter finds the start of records near offsets of a file.
+type splitter struct {
+	r    io.ReaderAt
+	size int64
+	// headerEnd is the offset of the first record. The header isn't sampled, since its fields may be
+	// quoted.
+	headerEnd int64
+	cs        charset.Charset
+	dialect   textformat.Dialect
+	// quote is the quote character of CSV files, or zero if records can't contain new lines.
+	quote byte
+	// columns is the number of fields of each CSV record.
+	columns int
+}
+
+// sample divides the file from start into ranges that end at the start of the first record after each
+// offset, by reading around the offsets. If a boundary is ambiguous, ErrAmbiguousBoundary is returned.
+func (sp splitter) sample(start int64, offsets []int64) (ranges [][]int64, err error) {
+	if sp.cs.Wide() {
+		return nil, ErrAmbiguousBoundary
+	}
+	for _, offset := range offsets {
+		if offset <= start || offset <= sp.headerEnd {
+			continue
+		}
+		var end int64
+		if end, err = sp.boundary(offset); err != nil {
+			return nil, err
+		}
+		if end <= start || end >= sp.size {
+			continue
+		}
+		ranges = append(ranges, []int64{start, end})
+		start = end
+	}
+	if start < sp.size {
+		ranges = append(ranges, []int64{start, sp.size})
+	}
+	return
+}
+
+// boundary returns the offset of the start of the first line after the offset. For CSV files, a quote
+// anywhere in the sampled window before the boundary, or in the line after it, may mean that the
+// boundary is within a quoted field, so the boundary is ambiguous. The line after the boundary is also
+// checked to make sure that it's a whole record.
+func (sp splitter) boundary(offset int64) (start int64, err error) {
+	r, cs, dialect, size, quote, columns := sp.r, sp.cs, sp.dialect, sp.size, sp.quote, sp.columns
+	from := offset - sampleWindow/2
+	if from < sp.headerEnd {
+		from = sp.headerEnd
+	}
+	p := make([]byte, sampleWindow)
+	n, err := r.ReadAt(p, from)
+	if err != nil && err != io.EOF {
+		return
+	}
+	err = nil
+	p = p[:n]
+	atEOF := from+int64(n) >= size
+
+	// Find the new line at or after the byte before the offset.
+	i := bytes.IndexByte(p[offset-1-from:], '\n')
+	if i < 0 {
+		if atEOF {
+			return size, nil
+		}
+		return 0, ErrAmbiguousBoundary
+	}
+	i += int(offset - 1 - from)
+	start = from + int64(i) + 1
+	if quote == 0 {
+		return
+	}
+
+	// Only a quoted field that starts before the window, and has no quotes within half a window of
+	// the offset, can't be detected.
+	prev := p[:i]
+	next := p[i+1:]
+	j := bytes.IndexByte(next, '\n')
+	if j < 0 && !atEOF {
+		return 0, ErrAmbiguousBoundary
+	}
+	if j >= 0 {
+		next = next[:j+1]
+	}
+	if bytes.IndexByte(prev, quote) >= 0 || bytes.IndexByte(next, quote) >= 0 {
+		return 0, ErrAmbiguousBoundary
+	}
+	if len(next) > 0 {
+		if next, err = cs.Decode(next); err != nil {
+			return 0, ErrAmbiguousBoundary
+		}
+		csvr := dialect.NewReader(bytes.NewReader(next))
+		csvr.FieldsPerRecord = columns
+		if _, err = csvr.Read(); err != nil {
+			return 0, ErrAmbiguousBoundary
+		}
+	}
+	return
+}
@@ -0,0 +1,182 @@
+package process
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/a-h/ddbimport/charset"
+	"github.com/a-h/ddbimport/sls/state"
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+)
+
+func TestSample(t *testing.T) {
+	var tests = []struct {
+		name            string
+		src             string
+		format          string
+		encoding        string
+		columns         []string
+		layout          string
+		batchBytes      int64
+		expectedBatches [][]int64
+		expectedColumns []string
+		expectedError   error
+	}{
+		{
+			name:       "batches start at the line after each offset",
+			src:        generate(4),
+			batchBytes: 8,
+			expectedBatches: [][]int64{
+				{0, 18},  // Header and first 2 rows. The first offset (14) is in the middle of the second row.
+				{18, 24}, // The next offset (22) is in the middle of the third row.
+				{24, 30}, // Remainder.
+			},
+			expectedColumns: []string{"a", "b", "c"},
+		},
+		{
+			name:       "offsets at the start of a line start a batch",
+			src:        generate(4),
+			batchBytes: 6,
+			expectedBatches: [][]int64{
+				{0, 12},
+				{12, 18},
+				{18, 24},
+				{24, 30},
+			},
+			expectedColumns: []string{"a", "b", "c"},
+		},
+		{
+			name:       "small files are a single batch",
+			src:        generate(2),
+			batchBytes: 1024,
+			expectedBatches: [][]int64{
+				{0, 18},
+			},
+			expectedColumns: []string{"a", "b", "c"},
+		},
+		{
+			name:       "JSON Lines files don't have a header",
+			src:        strings.Repeat(`{"a":"x"}`+"\n", 5),
+			format:     state.FormatJSONLines,
+			batchBytes: 15,
+			expectedBatches: [][]int64{
+				{0, 20},
+				{20, 30},
+				{30, 50},
+			},
+			expectedColumns: []string{},
+		},
+		{
+			name:          "quoted fields near an offset are ambiguous",
+			src:           "a,b,c\n" + strings.Repeat("x,\"y\nz\",w\n", 4),
+			batchBytes:    12,
+			expectedError: ErrAmbiguousBoundary,
+		},
+		{
+			name:          "UTF-16 files are always scanned",
+			src:           utf16LE(generate(4)),
+			encoding:      charset.UTF16LE,
+			batchBytes:    12,
+			expectedError: ErrAmbiguousBoundary,
+		},
+		{
+			name:       "Latin-1 files are sampled",
+			src:        "a,b\ncaf\xe9,x\ncaf\xe9,y\n",
+			encoding:   charset.Latin1,
+			batchBytes: 4,
+			expectedBatches: [][]int64{
+				{0, 11},
+				{11, 18},
+			},
+			expectedColumns: []string{"a", "b"},
+		},
+		{
+			name:       "files without a header use the supplied columns",
+			src:        strings.Repeat("x,y,z\n", 4),
+			columns:    []string{"a", "b", "c"},
+			batchBytes: 8,
+			expectedBatches: [][]int64{
+				{0, 12},
+				{12, 18},
+				{18, 24},
+			},
+			expectedColumns: []string{"a", "b", "c"},
+		},
+		{
+			name:       "fixed-width files don't have a header",
+			src:        strings.Repeat("0001Alice\n", 4),
+			format:     state.FormatFixedWidth,
+			layout:     "id:1:4,name:5:5",
+			batchBytes: 15,
+			expectedBatches: [][]int64{
+				{0, 20},
+				{20, 30},
+				{30, 40},
+			},
+			expectedColumns: []string{"id", "name"},
+		},
+		{
+			name:          "quotes before the boundary are ambiguous, even if the lines either side are records",
+			src:           "x,\"y\nA,B,C\nD,E,F\nz\",w",
+			columns:       []string{"a", "b", "c"},
+			batchBytes:    8,
+			expectedError: ErrAmbiguousBoundary,
+		},
+		{
+			name:       "quotes in the header aren't sampled",
+			src:        "\"a\",b,c\nx,y,z\nx,y,z\n",
+			batchBytes: 4,
+			expectedBatches: [][]int64{
+				{0, 14},
+				{14, 20},
+			},
+			expectedColumns: []string{"a", "b", "c"},
+		},
+		{
+			name:          "lines with the wrong number of fields are ambiguous",
+			src:           "a,b,c\nx,y,z\nx,y\nx,y,z\n",
+			batchBytes:    4,
+			expectedError: ErrAmbiguousBoundary,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var req state.State
+			req.Source.Delimiter = ","
+			req.Source.Format = tt.format
+			req.Source.Encoding = tt.encoding
+			req.Source.Columns = tt.columns
+			req.Source.Layout = tt.layout
+			resp, err := Sample(zap.New(nil), strings.NewReader(tt.src), int64(len(tt.src)), tt.batchBytes, req)
+			if err != tt.expectedError {
+				t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.expectedBatches, resp.Batches); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff(tt.expectedColumns, resp.Preflight.Columns); diff != "" {
+				t.Error(diff)
+			}
+			if resp.Preflight.Continue {
+				t.Error("expected sampling to complete in a single run")
+			}
+			// Each batch after the first must start at the beginning of a record.
+			if tt.format == state.FormatJSONLines || tt.format == state.FormatFixedWidth {
+				return
+			}
+			for _, b := range resp.Batches[1:] {
+				csvr := csv.NewReader(strings.NewReader(tt.src[b[0]:b[1]]))
+				csvr.FieldsPerRecord = len(tt.expectedColumns)
+				if _, err := csvr.ReadAll(); err != nil {
+					t.Errorf("failed to parse batch %v: %v", b, err)
+				}
+			}
+		})
+	}
+}
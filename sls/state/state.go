@@ -1,6 +1,10 @@
 package state
 
-import "time"
+import (
+	"time"
+
+	"github.com/a-h/ddbimport/textformat"
+)
 
 // Input to the ddbimport step function.
 type Input struct {
@@ -14,18 +18,43 @@ type Input struct {
 type State struct {
 	Input
 	Preflight Preflight `json:"prefl"`
-	// Batches of ranges (from, to).
+	// Batches of ranges (from, to), of bytes, or of rows for Parquet files.
 	Batches [][]int64 `json:"batches"`
 }
 
 // ImportInput is the input to the ddbimport.
 type ImportInput struct {
 	Input
-	// Range of bytes.
+	// Range of bytes, or of rows for Parquet files.
 	Range   []int64  `json:"range"`
 	Columns []string `json:"cols"`
 }
 
+// ImportOutput is the output of the ddbimport Lambda.
+type ImportOutput struct {
+	ProcessedCount int64 `json:"processedCount"`
+	SkippedCount   int64 `json:"skippedCount"`
+	RejectedCount  int64 `json:"rejectedCount"`
+	MaxItemSize    int   `json:"maxItemSize"`
+	DurationMS     int64 `json:"durationMs"`
+	// ItemCount and ItemBytes are the number and total size of the items that weren't rejected.
+	ItemCount int64 `json:"itemCount"`
+	ItemBytes int64 `json:"itemBytes"`
+	// ItemSizeHistogram is the number of items in each of a fixed number of buckets of sizes, which
+	// keeps the output of each batch small when the Map state collects them for the summary.
+	ItemSizeHistogram []int64 `json:"itemSizeHist"`
+}
+
+// Summary is the output of the ddbimport Step Function, which combines the outputs of the imports.
+type Summary struct {
+	ProcessedCount int64   `json:"processedCount"`
+	SkippedCount   int64   `json:"skippedCount"`
+	RejectedCount  int64   `json:"rejectedCount"`
+	MaxItemSize    int     `json:"maxItemSize"`
+	MeanItemSize   float64 `json:"meanItemSize"`
+	P99ItemSize    int     `json:"p99ItemSize"`
+}
+
 // Source of the CSV data to import.
 type Source struct {
 	Region        string   `json:"region"`
@@ -34,8 +63,60 @@ type Source struct {
 	NumericFields []string `json:"numFlds"`
 	BooleanFields []string `json:"boolFlds"`
 	Delimiter     string   `json:"delim"`
+	// Format of the file, csv, jsonl, fixed or parquet. Defaults to csv.
+	Format string `json:"fmt"`
+	// Encoding of the file, e.g. latin1 or utf-16le. Defaults to utf-8.
+	Encoding string `json:"enc"`
+	// Comment is the prefix of CSV lines that are ignored.
+	Comment string `json:"comment"`
+	// LazyQuotes allows quotes within unquoted CSV fields, and unescaped quotes within quoted fields.
+	LazyQuotes bool `json:"lazyQuotes"`
+	// TrimLeadingSpace ignores the whitespace at the start of each CSV field.
+	TrimLeadingSpace bool `json:"trimSpace"`
+	// SkipLines is the number of lines before the CSV header to ignore.
+	SkipLines int `json:"skipLines"`
+	// Columns of a CSV file without a header row. If set, the first record is data.
+	Columns []string `json:"cols"`
+	// Layout of the fields of a fixed-width file, e.g. id:1:8,name:9:20.
+	Layout string `json:"layout"`
+	// Trim is the rule used to remove padding from fixed-width fields. Defaults to both.
+	Trim string `json:"trim"`
 }
 
+// Dialect returns the CSV dialect of the source.
+func (s Source) Dialect() (d textformat.Dialect, err error) {
+	if d.Delimiter, err = textformat.ParseRune(s.Delimiter); err != nil {
+		return
+	}
+	if d.Comment, err = textformat.ParseRune(s.Comment); err != nil {
+		return
+	}
+	d.LazyQuotes = s.LazyQuotes
+	d.TrimLeadingSpace = s.TrimLeadingSpace
+	d.SkipLines = s.SkipLines
+	err = d.Validate()
+	return
+}
+
+// FixedWidth returns the layout of a fixed-width source.
+func (s Source) FixedWidth() (textformat.Layout, error) {
+	return textformat.ParseLayout(s.Layout, s.Trim)
+}
+
+// Formats of source files.
+const (
+	// FormatCSV is delimited data with a header row.
+	FormatCSV = "csv"
+	// FormatJSONLines is newline delimited JSON, one object per line.
+	FormatJSONLines = "jsonl"
+	// FormatFixedWidth is text with each field at a fixed position in the line.
+	FormatFixedWidth = "fixed"
+	// FormatParquet is columnar data, which is split into batches of rows instead of bytes.
+	FormatParquet = "parquet"
+	// FormatXLSX is an Excel workbook, which is only supported by local imports.
+	FormatXLSX = "xlsx"
+)
+
 // Configuration of the Step Function.
 type Configuration struct {
 	// LambdaConcurrency is the number of BatchWriteItem requests that will be executed in parallel.
@@ -43,8 +124,34 @@ type Configuration struct {
 	// LambdaDurationSeconds is the minimum amount of time each Lambda will spend executing tasks.
 	// After exceeding this, the preflight will start again.
 	LambdaDurationSeconds time.Duration `json:"lambdaDurSecs"`
+	// Mode of writing to the table, e.g. put, putIfNotExists, update or delete. Defaults to put.
+	Mode string `json:"mode"`
+	// ItemConcurrency is the number of single item requests (e.g. PutItem) executed in parallel
+	// for each batch, when the Mode doesn't use BatchWriteItem.
+	ItemConcurrency int `json:"itemConcur"`
+	// AddFields are numeric fields that are added to existing values in update mode.
+	AddFields []string `json:"addFlds"`
+	// RemoveEmpty removes attributes from existing items when the value is empty in update mode.
+	RemoveEmpty bool `json:"rmEmpty"`
+	// OnDuplicateKey is the policy for handling records with duplicate keys within a batch,
+	// i.e. lastWins, firstWins or error. Defaults to lastWins.
+	OnDuplicateKey string `json:"onDupKey"`
+	// ShuffleWindow is the number of records to buffer and interleave by partition key, so that
+	// batches are spread across partitions. Zero disables shuffling.
+	ShuffleWindow int `json:"shuffle"`
+	// Preflight is how the preflight divides the file into batches, scan or sample. Defaults to scan.
+	Preflight string `json:"preflMode"`
 }
 
+// Preflight modes.
+const (
+	// PreflightScan reads the whole file to find the start of each batch.
+	PreflightScan = "scan"
+	// PreflightSample reads around evenly spaced offsets to find the start of each batch, and falls
+	// back to scanning if the start of a record is ambiguous.
+	PreflightSample = "sample"
+)
+
 // Target DynamoDB table.
 type Target struct {
 	Region    string `json:"region"`
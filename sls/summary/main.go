@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+
+	"github.com/a-h/ddbimport/csvtodynamo"
+	"github.com/a-h/ddbimport/log"
+	"github.com/a-h/ddbimport/sls/state"
+	"github.com/aws/aws-lambda-go/lambda"
+	"go.uber.org/zap"
+)
+
+// Handler combines the outputs of the imports, merging the item size statistics so that the mean
+// and p99 item sizes are calculated across the whole file.
+func Handler(ctx context.Context, outputs []state.ImportOutput) (s state.Summary, err error) {
+	var stats csvtodynamo.SizeStats
+	for _, o := range outputs {
+		s.ProcessedCount += o.ProcessedCount
+		s.SkippedCount += o.SkippedCount
+		stats.Merge(&csvtodynamo.SizeStats{
+			Count:    o.ItemCount,
+			Total:    o.ItemBytes,
+			Max:      o.MaxItemSize,
+			Rejected: o.RejectedCount,
+		})
+		stats.AddHistogram(o.ItemSizeHistogram)
+	}
+	s.RejectedCount = stats.Rejected
+	s.MaxItemSize = stats.Max
+	s.MeanItemSize = stats.Mean()
+	s.P99ItemSize = stats.Percentile(99)
+	log.Default.Info("complete",
+		zap.Int("imports", len(outputs)),
+		zap.Int64("processed", s.ProcessedCount),
+		zap.Int64("skipped", s.SkippedCount),
+		zap.Int64("rejected", s.RejectedCount),
+		zap.Int("maxItemSize", s.MaxItemSize),
+		zap.Int("p99ItemSize", s.P99ItemSize),
+		zap.Float64("meanItemSize", s.MeanItemSize))
+	return
+}
+
+func main() {
+	lambda.Start(Handler)
+}
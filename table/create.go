@@ -0,0 +1,199 @@
+package table
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// ParseKeys parses a comma separated list of key attributes in the form name:type, e.g. "ngram:S,year:N".
+// The partition (hash) key is first, followed by the optional sort (range) key.
+func ParseKeys(s string) (keys []Attribute, err error) {
+	parts := strings.Split(s, ",")
+	if len(parts) > 2 {
+		err = fmt.Errorf("table: invalid keys %q: expected a partition key and optional sort key", s)
+		return
+	}
+	for _, p := range parts {
+		nameAndType := strings.SplitN(strings.TrimSpace(p), ":", 2)
+		if len(nameAndType) != 2 || nameAndType[0] == "" {
+			err = fmt.Errorf("table: invalid key %q: expected name:type", p)
+			return
+		}
+		t := strings.ToUpper(nameAndType[1])
+		if t != dynamodb.ScalarAttributeTypeS && t != dynamodb.ScalarAttributeTypeN && t != dynamodb.ScalarAttributeTypeB {
+			err = fmt.Errorf("table: invalid key %q: type must be S, N or B", p)
+			return
+		}
+		keys = append(keys, Attribute{Name: nameAndType[0], Type: t})
+	}
+	return
+}
+
+// ParseIndexes parses a semicolon separated list of indexes in the form name=keys, where keys are
+// in the format used by ParseKeys, e.g. "byCount=match_count:N;byYear=year:N,ngram:S".
+func ParseIndexes(s string) (indexes []Index, err error) {
+	if s == "" {
+		return
+	}
+	for _, p := range strings.Split(s, ";") {
+		nameAndKeys := strings.SplitN(strings.TrimSpace(p), "=", 2)
+		if len(nameAndKeys) != 2 || nameAndKeys[0] == "" {
+			err = fmt.Errorf("table: invalid index %q: expected name=keys", p)
+			return
+		}
+		var keys []Attribute
+		if keys, err = ParseKeys(nameAndKeys[1]); err != nil {
+			return
+		}
+		indexes = append(indexes, Index{Name: nameAndKeys[0], Keys: keys})
+	}
+	return
+}
+
+// Create a DynamoDB table with the schema if it doesn't already exist, and wait for it to become active.
+// If the table exists, it must have the same keys as the schema, and it's waited for if it's still being
+// created or updated. The indexes are created as global secondary indexes. If the schema has provisioned write capacity, the
+// table and indexes use provisioned capacity, otherwise they use on-demand capacity.
+func Create(region, tableName string, s Schema) (created bool, err error) {
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return
+	}
+	return create(dynamodb.New(sess), tableName, s)
+}
+
+func create(client dynamodbiface.DynamoDBAPI, tableName string, s Schema) (created bool, err error) {
+	dto, err := client.DescribeTable(&dynamodb.DescribeTableInput{
+		TableName: aws.String(tableName),
+	})
+	if err == nil {
+		return false, existing(client, tableName, dto.Table, s)
+	}
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) || aerr.Code() != dynamodb.ErrCodeResourceNotFoundException {
+		err = fmt.Errorf("table: failed to describe table %q: %w", tableName, err)
+		return
+	}
+	_, err = client.CreateTable(createTableInput(tableName, s))
+	if err != nil {
+		err = fmt.Errorf("table: failed to create table %q: %w", tableName, err)
+		return
+	}
+	created = true
+	err = client.WaitUntilTableExists(&dynamodb.DescribeTableInput{
+		TableName: aws.String(tableName),
+	})
+	if err != nil {
+		err = fmt.Errorf("table: failed waiting for table %q to become active: %w", tableName, err)
+	}
+	return
+}
+
+// existing checks that the keys of an existing table match the schema, and waits for the table to
+// become active.
+func existing(client dynamodbiface.DynamoDBAPI, tableName string, td *dynamodb.TableDescription, s Schema) (err error) {
+	types := make(map[string]string, len(td.AttributeDefinitions))
+	for _, ad := range td.AttributeDefinitions {
+		types[*ad.AttributeName] = *ad.AttributeType
+	}
+	if actual := keys(td.KeySchema, types); !sameKeys(actual, s.Keys) {
+		return fmt.Errorf("table: table %q already exists with keys %s, not %s", tableName, formatKeys(actual), formatKeys(s.Keys))
+	}
+	switch aws.StringValue(td.TableStatus) {
+	case dynamodb.TableStatusCreating, dynamodb.TableStatusUpdating:
+		err = client.WaitUntilTableExists(&dynamodb.DescribeTableInput{
+			TableName: aws.String(tableName),
+		})
+		if err != nil {
+			err = fmt.Errorf("table: failed waiting for table %q to become active: %w", tableName, err)
+		}
+	}
+	return
+}
+
+func sameKeys(a, b []Attribute) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// formatKeys formats keys in the format used by ParseKeys.
+func formatKeys(keys []Attribute) string {
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k.Name + ":" + k.Type
+	}
+	return strings.Join(parts, ",")
+}
+
+func createTableInput(tableName string, s Schema) *dynamodb.CreateTableInput {
+	cti := &dynamodb.CreateTableInput{
+		TableName: aws.String(tableName),
+		KeySchema: keySchema(s.Keys),
+	}
+	var throughput *dynamodb.ProvisionedThroughput
+	if s.WriteCapacityUnits > 0 {
+		cti.BillingMode = aws.String(dynamodb.BillingModeProvisioned)
+		throughput = &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(s.ReadCapacityUnits),
+			WriteCapacityUnits: aws.Int64(s.WriteCapacityUnits),
+		}
+		cti.ProvisionedThroughput = throughput
+	} else {
+		cti.BillingMode = aws.String(dynamodb.BillingModePayPerRequest)
+	}
+	// Each attribute can only be defined once, even if it's used by the table and an index.
+	defined := map[string]bool{}
+	define := func(attributes []Attribute) {
+		for _, a := range attributes {
+			if defined[a.Name] {
+				continue
+			}
+			defined[a.Name] = true
+			cti.AttributeDefinitions = append(cti.AttributeDefinitions, &dynamodb.AttributeDefinition{
+				AttributeName: aws.String(a.Name),
+				AttributeType: aws.String(a.Type),
+			})
+		}
+	}
+	define(s.Keys)
+	for _, index := range s.Indexes {
+		define(index.Keys)
+		cti.GlobalSecondaryIndexes = append(cti.GlobalSecondaryIndexes, &dynamodb.GlobalSecondaryIndex{
+			IndexName: aws.String(index.Name),
+			KeySchema: keySchema(index.Keys),
+			Projection: &dynamodb.Projection{
+				ProjectionType: aws.String(dynamodb.ProjectionTypeAll),
+			},
+			ProvisionedThroughput: throughput,
+		})
+	}
+	return cti
+}
+
+func keySchema(keys []Attribute) (kse []*dynamodb.KeySchemaElement) {
+	for i, k := range keys {
+		keyType := dynamodb.KeyTypeHash
+		if i > 0 {
+			keyType = dynamodb.KeyTypeRange
+		}
+		kse = append(kse, &dynamodb.KeySchemaElement{
+			AttributeName: aws.String(k.Name),
+			KeyType:       aws.String(keyType),
+		})
+	}
+	return
+}
@@ -0,0 +1,226 @@
+package table
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseKeys(t *testing.T) {
+	var tests = []struct {
+		input         string
+		expected      []Attribute
+		expectedError string
+	}{
+		{
+			input:    "id:S",
+			expected: []Attribute{{Name: "id", Type: "S"}},
+		},
+		{
+			input:    "ngram:s, year:N",
+			expected: []Attribute{{Name: "ngram", Type: "S"}, {Name: "year", Type: "N"}},
+		},
+		{
+			input:         "id",
+			expectedError: `table: invalid key "id": expected name:type`,
+		},
+		{
+			input:         "id:X",
+			expectedError: `table: invalid key "id:X": type must be S, N or B`,
+		},
+		{
+			input:         "a:S,b:S,c:S",
+			expectedError: `table: invalid keys "a:S,b:S,c:S": expected a partition key and optional sort key`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.input, func(t *testing.T) {
+			actual, err := ParseKeys(tt.input)
+			var actualError string
+			if err != nil {
+				actualError = err.Error()
+			}
+			if actualError != tt.expectedError {
+				t.Errorf("expected error %q, got %q", tt.expectedError, actualError)
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestParseIndexes(t *testing.T) {
+	actual, err := ParseIndexes("byCount=match_count:N;byYear=year:N,ngram:S")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Index{
+		{Name: "byCount", Keys: []Attribute{{Name: "match_count", Type: "N"}}},
+		{Name: "byYear", Keys: []Attribute{{Name: "year", Type: "N"}, {Name: "ngram", Type: "S"}}},
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Error(diff)
+	}
+	if _, err = ParseIndexes("byCount"); err == nil {
+		t.Error("expected an error for an index without keys")
+	}
+}
+
+type mockCreator struct {
+	dynamodbiface.DynamoDBAPI
+	existing *dynamodb.TableDescription
+	created  *dynamodb.CreateTableInput
+	waited   bool
+}
+
+func (m *mockCreator) DescribeTable(*dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+	if m.existing != nil {
+		return &dynamodb.DescribeTableOutput{Table: m.existing}, nil
+	}
+	return nil, awserr.New(dynamodb.ErrCodeResourceNotFoundException, "not found", nil)
+}
+
+func (m *mockCreator) CreateTable(input *dynamodb.CreateTableInput) (*dynamodb.CreateTableOutput, error) {
+	m.created = input
+	return &dynamodb.CreateTableOutput{}, nil
+}
+
+func (m *mockCreator) WaitUntilTableExists(*dynamodb.DescribeTableInput) error {
+	m.waited = true
+	return nil
+}
+
+func TestCreate(t *testing.T) {
+	existing := func(status string, keys ...Attribute) *dynamodb.TableDescription {
+		td := &dynamodb.TableDescription{TableStatus: aws.String(status)}
+		for i, k := range keys {
+			keyType := "HASH"
+			if i > 0 {
+				keyType = "RANGE"
+			}
+			td.KeySchema = append(td.KeySchema, &dynamodb.KeySchemaElement{AttributeName: aws.String(k.Name), KeyType: aws.String(keyType)})
+			td.AttributeDefinitions = append(td.AttributeDefinitions, &dynamodb.AttributeDefinition{AttributeName: aws.String(k.Name), AttributeType: aws.String(k.Type)})
+		}
+		return td
+	}
+	t.Run("existing tables are not created", func(t *testing.T) {
+		client := &mockCreator{existing: existing("ACTIVE", Attribute{Name: "id", Type: "S"})}
+		created, err := create(client, "test", Schema{Keys: []Attribute{{Name: "id", Type: "S"}}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if created || client.created != nil || client.waited {
+			t.Error("expected the table not to be created or waited for")
+		}
+	})
+	t.Run("existing tables that are being created are waited for", func(t *testing.T) {
+		for _, status := range []string{"CREATING", "UPDATING"} {
+			client := &mockCreator{existing: existing(status, Attribute{Name: "id", Type: "S"})}
+			if _, err := create(client, "test", Schema{Keys: []Attribute{{Name: "id", Type: "S"}}}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !client.waited {
+				t.Errorf("expected a %s table to be waited for", status)
+			}
+		}
+	})
+	t.Run("existing tables must have the same keys", func(t *testing.T) {
+		var tests = []struct {
+			name     string
+			keys     []Attribute
+			expected string
+		}{
+			{
+				name:     "type",
+				keys:     []Attribute{{Name: "id", Type: "N"}},
+				expected: `table: table "test" already exists with keys id:N, not id:S`,
+			},
+			{
+				name:     "name",
+				keys:     []Attribute{{Name: "pk", Type: "S"}},
+				expected: `table: table "test" already exists with keys pk:S, not id:S`,
+			},
+			{
+				name:     "sort key",
+				keys:     []Attribute{{Name: "id", Type: "S"}, {Name: "sk", Type: "S"}},
+				expected: `table: table "test" already exists with keys id:S,sk:S, not id:S`,
+			},
+		}
+		for _, tt := range tests {
+			client := &mockCreator{existing: existing("ACTIVE", tt.keys...)}
+			_, err := create(client, "test", Schema{Keys: []Attribute{{Name: "id", Type: "S"}}})
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("%s: expected error %q, got %v", tt.name, tt.expected, err)
+			}
+		}
+	})
+	t.Run("on-demand tables are created and waited for", func(t *testing.T) {
+		client := &mockCreator{}
+		created, err := create(client, "test", Schema{
+			Keys: []Attribute{{Name: "ngram", Type: "S"}, {Name: "year", Type: "N"}},
+			Indexes: []Index{
+				{Name: "byYear", Keys: []Attribute{{Name: "year", Type: "N"}, {Name: "match_count", Type: "N"}}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !created || !client.waited {
+			t.Errorf("expected the table to be created and waited for, got created %v, waited %v", created, client.waited)
+		}
+		expected := &dynamodb.CreateTableInput{
+			TableName:   aws.String("test"),
+			BillingMode: aws.String("PAY_PER_REQUEST"),
+			AttributeDefinitions: []*dynamodb.AttributeDefinition{
+				{AttributeName: aws.String("ngram"), AttributeType: aws.String("S")},
+				{AttributeName: aws.String("year"), AttributeType: aws.String("N")},
+				{AttributeName: aws.String("match_count"), AttributeType: aws.String("N")},
+			},
+			KeySchema: []*dynamodb.KeySchemaElement{
+				{AttributeName: aws.String("ngram"), KeyType: aws.String("HASH")},
+				{AttributeName: aws.String("year"), KeyType: aws.String("RANGE")},
+			},
+			GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndex{
+				{
+					IndexName: aws.String("byYear"),
+					KeySchema: []*dynamodb.KeySchemaElement{
+						{AttributeName: aws.String("year"), KeyType: aws.String("HASH")},
+						{AttributeName: aws.String("match_count"), KeyType: aws.String("RANGE")},
+					},
+					Projection: &dynamodb.Projection{ProjectionType: aws.String("ALL")},
+				},
+			},
+		}
+		if diff := cmp.Diff(expected, client.created); diff != "" {
+			t.Error(diff)
+		}
+	})
+	t.Run("provisioned tables set the capacity of the table and indexes", func(t *testing.T) {
+		client := &mockCreator{}
+		_, err := create(client, "test", Schema{
+			Keys:               []Attribute{{Name: "id", Type: "S"}},
+			Indexes:            []Index{{Name: "byEmail", Keys: []Attribute{{Name: "email", Type: "S"}}}},
+			ReadCapacityUnits:  5,
+			WriteCapacityUnits: 100,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if *client.created.BillingMode != "PROVISIONED" {
+			t.Errorf("expected provisioned billing mode, got %q", *client.created.BillingMode)
+		}
+		expected := &dynamodb.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(5), WriteCapacityUnits: aws.Int64(100)}
+		if diff := cmp.Diff(expected, client.created.ProvisionedThroughput); diff != "" {
+			t.Error(diff)
+		}
+		if diff := cmp.Diff(expected, client.created.GlobalSecondaryIndexes[0].ProvisionedThroughput); diff != "" {
+			t.Error(diff)
+		}
+	})
+}
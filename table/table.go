@@ -0,0 +1,93 @@
+package table
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// Attribute is a named and typed attribute used within a key.
+type Attribute struct {
+	Name string
+	// Type is the DynamoDB scalar type of the attribute, i.e. S, N or B.
+	Type string
+}
+
+// Schema of a DynamoDB table.
+type Schema struct {
+	// Keys of the table. The partition (hash) key is first, followed by the optional sort (range) key.
+	Keys []Attribute
+	// Indexes are the global and local secondary indexes of the table.
+	Indexes []Index
+	// ReadCapacityUnits is the provisioned read capacity of the table, or zero if the table uses on-demand capacity.
+	ReadCapacityUnits int64
+	// WriteCapacityUnits is the provisioned write capacity of the table, or zero if the table uses on-demand capacity.
+	WriteCapacityUnits int64
+}
+
+// Index is a secondary index of a table.
+type Index struct {
+	Name string
+	// Keys of the index. The partition (hash) key is first, followed by the optional sort (range) key.
+	Keys []Attribute
+}
+
+// KeyNames returns the names of the key attributes.
+func (s Schema) KeyNames() (names []string) {
+	names = make([]string, len(s.Keys))
+	for i, k := range s.Keys {
+		names[i] = k.Name
+	}
+	return
+}
+
+// Describe a DynamoDB table to find its schema.
+func Describe(region, tableName string) (s Schema, err error) {
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return
+	}
+	return describe(dynamodb.New(sess), tableName)
+}
+
+func describe(client dynamodbiface.DynamoDBAPI, tableName string) (s Schema, err error) {
+	dto, err := client.DescribeTable(&dynamodb.DescribeTableInput{
+		TableName: aws.String(tableName),
+	})
+	if err != nil {
+		err = fmt.Errorf("table: failed to describe table %q: %w", tableName, err)
+		return
+	}
+	types := make(map[string]string, len(dto.Table.AttributeDefinitions))
+	for _, ad := range dto.Table.AttributeDefinitions {
+		types[*ad.AttributeName] = *ad.AttributeType
+	}
+	s.Keys = keys(dto.Table.KeySchema, types)
+	for _, gsi := range dto.Table.GlobalSecondaryIndexes {
+		s.Indexes = append(s.Indexes, Index{Name: *gsi.IndexName, Keys: keys(gsi.KeySchema, types)})
+	}
+	for _, lsi := range dto.Table.LocalSecondaryIndexes {
+		s.Indexes = append(s.Indexes, Index{Name: *lsi.IndexName, Keys: keys(lsi.KeySchema, types)})
+	}
+	if pt := dto.Table.ProvisionedThroughput; pt != nil {
+		s.ReadCapacityUnits = aws.Int64Value(pt.ReadCapacityUnits)
+		s.WriteCapacityUnits = aws.Int64Value(pt.WriteCapacityUnits)
+	}
+	return
+}
+
+func keys(kse []*dynamodb.KeySchemaElement, types map[string]string) (attributes []Attribute) {
+	attributes = make([]Attribute, len(kse))
+	for _, k := range kse {
+		a := Attribute{Name: *k.AttributeName, Type: types[*k.AttributeName]}
+		if *k.KeyType == dynamodb.KeyTypeHash {
+			attributes[0] = a
+			continue
+		}
+		attributes[len(attributes)-1] = a
+	}
+	return
+}
@@ -0,0 +1,122 @@
+package table
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/google/go-cmp/cmp"
+)
+
+type mockDescriber struct {
+	dynamodbiface.DynamoDBAPI
+	output *dynamodb.DescribeTableOutput
+}
+
+func (m mockDescriber) DescribeTable(*dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+	return m.output, nil
+}
+
+func TestDescribe(t *testing.T) {
+	var tests = []struct {
+		name     string
+		table    *dynamodb.TableDescription
+		expected Schema
+	}{
+		{
+			name: "partition key only",
+			table: &dynamodb.TableDescription{
+				AttributeDefinitions: []*dynamodb.AttributeDefinition{
+					{AttributeName: aws.String("id"), AttributeType: aws.String("S")},
+				},
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("id"), KeyType: aws.String("HASH")},
+				},
+			},
+			expected: Schema{
+				Keys: []Attribute{{Name: "id", Type: "S"}},
+			},
+		},
+		{
+			name: "the partition key is always first",
+			table: &dynamodb.TableDescription{
+				AttributeDefinitions: []*dynamodb.AttributeDefinition{
+					{AttributeName: aws.String("ngram"), AttributeType: aws.String("S")},
+					{AttributeName: aws.String("year"), AttributeType: aws.String("N")},
+				},
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("year"), KeyType: aws.String("RANGE")},
+					{AttributeName: aws.String("ngram"), KeyType: aws.String("HASH")},
+				},
+			},
+			expected: Schema{
+				Keys: []Attribute{{Name: "ngram", Type: "S"}, {Name: "year", Type: "N"}},
+			},
+		},
+		{
+			name: "secondary indexes are included",
+			table: &dynamodb.TableDescription{
+				AttributeDefinitions: []*dynamodb.AttributeDefinition{
+					{AttributeName: aws.String("id"), AttributeType: aws.String("S")},
+					{AttributeName: aws.String("email"), AttributeType: aws.String("S")},
+					{AttributeName: aws.String("created"), AttributeType: aws.String("N")},
+				},
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("id"), KeyType: aws.String("HASH")},
+				},
+				GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndexDescription{
+					{
+						IndexName: aws.String("byEmail"),
+						KeySchema: []*dynamodb.KeySchemaElement{
+							{AttributeName: aws.String("email"), KeyType: aws.String("HASH")},
+							{AttributeName: aws.String("created"), KeyType: aws.String("RANGE")},
+						},
+					},
+				},
+			},
+			expected: Schema{
+				Keys: []Attribute{{Name: "id", Type: "S"}},
+				Indexes: []Index{
+					{
+						Name: "byEmail",
+						Keys: []Attribute{{Name: "email", Type: "S"}, {Name: "created", Type: "N"}},
+					},
+				},
+			},
+		},
+		{
+			name: "provisioned write capacity is included",
+			table: &dynamodb.TableDescription{
+				AttributeDefinitions: []*dynamodb.AttributeDefinition{
+					{AttributeName: aws.String("id"), AttributeType: aws.String("S")},
+				},
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("id"), KeyType: aws.String("HASH")},
+				},
+				ProvisionedThroughput: &dynamodb.ProvisionedThroughputDescription{
+					ReadCapacityUnits:  aws.Int64(5),
+					WriteCapacityUnits: aws.Int64(100),
+				},
+			},
+			expected: Schema{
+				Keys:               []Attribute{{Name: "id", Type: "S"}},
+				ReadCapacityUnits:  5,
+				WriteCapacityUnits: 100,
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			client := mockDescriber{output: &dynamodb.DescribeTableOutput{Table: tt.table}}
+			actual, err := describe(client, "test")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package table
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Validate that columns contain the table's key attributes, and that columnType, which
+// returns the DynamoDB type that a column is converted to, matches the type of each
+// table and index key attribute.
+// Items don't need to contain index key attributes, so missing index key attributes are
+// returned as warnings instead of errors.
+func Validate(s Schema, columns []string, columnType func(column string) string) (warnings []string, err error) {
+	hasColumn := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		hasColumn[c] = true
+	}
+	var errs []string
+	for _, k := range s.Keys {
+		if !hasColumn[k.Name] {
+			errs = append(errs, fmt.Sprintf("table key attribute %q is missing from the columns %v", k.Name, columns))
+			continue
+		}
+		if actual := columnType(k.Name); actual != k.Type {
+			errs = append(errs, fmt.Sprintf("table key attribute %q has type %s, but the column is converted to %s", k.Name, k.Type, actual))
+		}
+	}
+	for _, index := range s.Indexes {
+		for _, k := range index.Keys {
+			if !hasColumn[k.Name] {
+				warnings = append(warnings, fmt.Sprintf("index %q key attribute %q is missing from the columns, so items won't be added to the index", index.Name, k.Name))
+				continue
+			}
+			if actual := columnType(k.Name); actual != k.Type {
+				errs = append(errs, fmt.Sprintf("index %q key attribute %q has type %s, but the column is converted to %s", index.Name, k.Name, k.Type, actual))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		err = fmt.Errorf("table: validation failed: %s", strings.Join(errs, "; "))
+	}
+	return
+}
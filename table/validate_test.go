@@ -0,0 +1,81 @@
+package table
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidate(t *testing.T) {
+	schema := Schema{
+		Keys: []Attribute{{Name: "ngram", Type: "S"}, {Name: "year", Type: "N"}},
+		Indexes: []Index{
+			{Name: "byCount", Keys: []Attribute{{Name: "match_count", Type: "N"}}},
+		},
+	}
+	numeric := func(names ...string) func(string) string {
+		return func(column string) string {
+			for _, n := range names {
+				if n == column {
+					return "N"
+				}
+			}
+			return "S"
+		}
+	}
+	var tests = []struct {
+		name             string
+		columns          []string
+		columnType       func(string) string
+		expectedWarnings []string
+		expectedError    string
+	}{
+		{
+			name:       "matching columns and types are valid",
+			columns:    []string{"ngram", "year", "match_count"},
+			columnType: numeric("year", "match_count"),
+		},
+		{
+			name:          "missing table keys are errors",
+			columns:       []string{"ngram", "match_count"},
+			columnType:    numeric("year", "match_count"),
+			expectedError: `table: validation failed: table key attribute "year" is missing from the columns [ngram match_count]`,
+		},
+		{
+			name:          "mismatched table key types are errors",
+			columns:       []string{"ngram", "year", "match_count"},
+			columnType:    numeric("match_count"),
+			expectedError: `table: validation failed: table key attribute "year" has type N, but the column is converted to S`,
+		},
+		{
+			name:          "mismatched index key types are errors",
+			columns:       []string{"ngram", "year", "match_count"},
+			columnType:    numeric("year"),
+			expectedError: `table: validation failed: index "byCount" key attribute "match_count" has type N, but the column is converted to S`,
+		},
+		{
+			name:       "missing index keys are warnings",
+			columns:    []string{"ngram", "year"},
+			columnType: numeric("year"),
+			expectedWarnings: []string{
+				`index "byCount" key attribute "match_count" is missing from the columns, so items won't be added to the index`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := Validate(schema, tt.columns, tt.columnType)
+			var actualError string
+			if err != nil {
+				actualError = err.Error()
+			}
+			if actualError != tt.expectedError {
+				t.Errorf("expected error %q, got %q", tt.expectedError, actualError)
+			}
+			if diff := cmp.Diff(tt.expectedWarnings, warnings); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
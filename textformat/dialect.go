@@ -0,0 +1,85 @@
+package textformat
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Dialect of a CSV file.
+type Dialect struct {
+	// Delimiter between fields. Defaults to a comma.
+	Delimiter rune
+	// Comment is the prefix of lines that are ignored. Zero disables comments.
+	Comment rune
+	// LazyQuotes allows quotes within unquoted fields, and unescaped quotes within quoted fields.
+	LazyQuotes bool
+	// TrimLeadingSpace ignores the whitespace at the start of each field.
+	TrimLeadingSpace bool
+	// SkipLines is the number of lines before the header to ignore, e.g. a title.
+	SkipLines int
+}
+
+// NewReader creates a CSV reader for the dialect.
+func (d Dialect) NewReader(r io.Reader) *csv.Reader {
+	csvr := csv.NewReader(r)
+	if d.Delimiter != 0 {
+		csvr.Comma = d.Delimiter
+	}
+	csvr.Comment = d.Comment
+	csvr.LazyQuotes = d.LazyQuotes
+	csvr.TrimLeadingSpace = d.TrimLeadingSpace
+	return csvr
+}
+
+// Validate returns an error if the CSV reader can't use the dialect.
+func (d Dialect) Validate() error {
+	delimiter := d.Delimiter
+	if delimiter == 0 {
+		delimiter = ','
+	}
+	if !validRune(delimiter) {
+		return fmt.Errorf("textformat: invalid delimiter %q", delimiter)
+	}
+	if d.Comment != 0 && (!validRune(d.Comment) || d.Comment == delimiter) {
+		return fmt.Errorf("textformat: invalid comment prefix %q", d.Comment)
+	}
+	if d.SkipLines < 0 {
+		return fmt.Errorf("textformat: invalid number of lines to skip %d", d.SkipLines)
+	}
+	return nil
+}
+
+func validRune(r rune) bool {
+	return r != 0 && r != '"' && r != '\r' && r != '\n' && utf8.ValidRune(r) && r != utf8.RuneError
+}
+
+var namedRunes = map[string]rune{
+	"comma":     ',',
+	"tab":       '\t',
+	"pipe":      '|',
+	"semicolon": ';',
+	"space":     ' ',
+}
+
+// ParseRune parses a delimiter or comment prefix, which is either a name (comma, tab, pipe, semicolon
+// or space), a single character, or an escape sequence such as \x1f or \u001f. An empty string is zero.
+func ParseRune(s string) (r rune, err error) {
+	if s == "" {
+		return 0, nil
+	}
+	if r, ok := namedRunes[s]; ok {
+		return r, nil
+	}
+	if utf8.RuneCountInString(s) == 1 {
+		r, _ = utf8.DecodeRuneInString(s)
+		return r, nil
+	}
+	r, _, tail, err := strconv.UnquoteChar(s, 0)
+	if err != nil || tail != "" {
+		return 0, fmt.Errorf("textformat: expected a single character, name or escape sequence, got %q", s)
+	}
+	return r, nil
+}
@@ -0,0 +1,83 @@
+package textformat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseRune(t *testing.T) {
+	var tests = []struct {
+		input         string
+		expected      rune
+		expectedError bool
+	}{
+		{input: "", expected: 0},
+		{input: "tab", expected: '\t'},
+		{input: "pipe", expected: '|'},
+		{input: "semicolon", expected: ';'},
+		{input: ";", expected: ';'},
+		{input: "§", expected: '§'},
+		{input: `\x1f`, expected: '\x1f'},
+		{input: `\u001e`, expected: '\x1e'},
+		{input: `\t`, expected: '\t'},
+		{input: "ab", expectedError: true},
+		{input: `\x1f\x1f`, expectedError: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.input, func(t *testing.T) {
+			actual, err := ParseRune(tt.input)
+			if (err != nil) != tt.expectedError {
+				t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+			}
+			if actual != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDialectValidate(t *testing.T) {
+	var tests = []struct {
+		name          string
+		dialect       Dialect
+		expectedError bool
+	}{
+		{name: "the default dialect is valid"},
+		{name: "quotes can't be delimiters", dialect: Dialect{Delimiter: '"'}, expectedError: true},
+		{name: "new lines can't be delimiters", dialect: Dialect{Delimiter: '\n'}, expectedError: true},
+		{name: "the comment prefix can't be the delimiter", dialect: Dialect{Delimiter: '#', Comment: '#'}, expectedError: true},
+		{name: "the comment prefix can't be the default delimiter", dialect: Dialect{Comment: ','}, expectedError: true},
+		{name: "lines to skip can't be negative", dialect: Dialect{SkipLines: -1}, expectedError: true},
+		{name: "comments can be used with other delimiters", dialect: Dialect{Delimiter: '|', Comment: '#'}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.dialect.Validate()
+			if (err != nil) != tt.expectedError {
+				t.Errorf("expected error %v, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestDialectNewReader(t *testing.T) {
+	d := Dialect{
+		Delimiter:        '\x1f',
+		Comment:          '#',
+		LazyQuotes:       true,
+		TrimLeadingSpace: true,
+	}
+	input := "# exported by a partner\na\x1f b\n" + `x"y` + "\x1f  z\n"
+	actual, err := d.NewReader(strings.NewReader(input)).ReadAll()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := [][]string{{"a", "b"}, {`x"y`, "z"}}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,124 @@
+package textformat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Trimming rules for the spaces that pad each fixed-width field.
+const (
+	TrimBoth  = "both"
+	TrimLeft  = "left"
+	TrimRight = "right"
+	TrimNone  = "none"
+)
+
+// Field of a fixed-width record.
+type Field struct {
+	Name string
+	// Start is the position of the first character of the field, starting at 1.
+	Start int
+	// Length is the number of characters in the field.
+	Length int
+}
+
+// Layout of the fields in each line of a fixed-width file.
+type Layout struct {
+	Fields []Field
+	// Trim is the rule used to remove padding from each field. Defaults to TrimBoth.
+	Trim string
+}
+
+// ParseLayout parses a comma separated list of fixed-width fields, each in the form name:start:length,
+// e.g. id:1:8,name:9:20, and the trimming rule.
+func ParseLayout(fields, trim string) (l Layout, err error) {
+	switch trim {
+	case "", TrimBoth, TrimLeft, TrimRight, TrimNone:
+		l.Trim = trim
+	default:
+		return l, fmt.Errorf("textformat: unknown trimming rule %q, expected one of %s, %s, %s or %s", trim, TrimBoth, TrimLeft, TrimRight, TrimNone)
+	}
+	if strings.TrimSpace(fields) == "" {
+		return l, fmt.Errorf("textformat: no fields")
+	}
+	names := map[string]bool{}
+	for _, s := range strings.Split(fields, ",") {
+		parts := strings.Split(strings.TrimSpace(s), ":")
+		if len(parts) != 3 || parts[0] == "" {
+			return l, fmt.Errorf("textformat: invalid field %q, expected name:start:length", s)
+		}
+		f := Field{Name: parts[0]}
+		if f.Start, err = strconv.Atoi(parts[1]); err != nil || f.Start < 1 {
+			return l, fmt.Errorf("textformat: field %q has an invalid start %q, expected a position starting at 1", f.Name, parts[1])
+		}
+		if f.Length, err = strconv.Atoi(parts[2]); err != nil || f.Length < 1 {
+			return l, fmt.Errorf("textformat: field %q has an invalid length %q", f.Name, parts[2])
+		}
+		if names[f.Name] {
+			return l, fmt.Errorf("textformat: duplicate field %q", f.Name)
+		}
+		names[f.Name] = true
+		l.Fields = append(l.Fields, f)
+	}
+	return l, nil
+}
+
+// Columns returns the names of the fields.
+func (l Layout) Columns() []string {
+	columns := make([]string, len(l.Fields))
+	for i, f := range l.Fields {
+		columns[i] = f.Name
+	}
+	return columns
+}
+
+// Record splits a line into its fields. Fields past the end of the line are empty, since trailing
+// spaces are often removed.
+func (l Layout) Record(line string) []string {
+	// Positions are characters, so lines containing multi-byte characters are split as runes.
+	var chars []rune
+	n := len(line)
+	if !isASCII(line) {
+		chars = []rune(line)
+		n = len(chars)
+	}
+	record := make([]string, len(l.Fields))
+	for i, f := range l.Fields {
+		from, to := f.Start-1, f.Start-1+f.Length
+		if from >= n {
+			continue
+		}
+		if to > n {
+			to = n
+		}
+		if chars == nil {
+			record[i] = l.trim(line[from:to])
+			continue
+		}
+		record[i] = l.trim(string(chars[from:to]))
+	}
+	return record
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+func (l Layout) trim(s string) string {
+	switch l.Trim {
+	case TrimLeft:
+		return strings.TrimLeft(s, " ")
+	case TrimRight:
+		return strings.TrimRight(s, " ")
+	case TrimNone:
+		return s
+	}
+	return strings.Trim(s, " ")
+}
@@ -0,0 +1,96 @@
+package textformat
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseLayout(t *testing.T) {
+	var tests = []struct {
+		name          string
+		fields        string
+		trim          string
+		expected      Layout
+		expectedError bool
+	}{
+		{
+			name:   "fields",
+			fields: "id:1:8, name:9:20",
+			trim:   TrimRight,
+			expected: Layout{
+				Fields: []Field{{Name: "id", Start: 1, Length: 8}, {Name: "name", Start: 9, Length: 20}},
+				Trim:   TrimRight,
+			},
+		},
+		{name: "no fields", fields: "", expectedError: true},
+		{name: "missing length", fields: "id:1", expectedError: true},
+		{name: "positions start at 1", fields: "id:0:8", expectedError: true},
+		{name: "lengths must be positive", fields: "id:1:0", expectedError: true},
+		{name: "names must be unique", fields: "id:1:8,id:9:8", expectedError: true},
+		{name: "unknown trimming rule", fields: "id:1:8", trim: "middle", expectedError: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := ParseLayout(tt.fields, tt.trim)
+			if (err != nil) != tt.expectedError {
+				t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestRecord(t *testing.T) {
+	fields := []Field{{Name: "id", Start: 1, Length: 4}, {Name: "name", Start: 5, Length: 6}, {Name: "code", Start: 11, Length: 3}}
+	var tests = []struct {
+		name     string
+		trim     string
+		line     string
+		expected []string
+	}{
+		{
+			name:     "padding is trimmed from both sides by default",
+			line:     "0042 café ABC",
+			expected: []string{"0042", "café", "ABC"},
+		},
+		{
+			name:     "fields past the end of the line are empty",
+			line:     "0042 Bob",
+			expected: []string{"0042", "Bob", ""},
+		},
+		{
+			name:     "left",
+			trim:     TrimLeft,
+			line:     "  42 Bob  ABC",
+			expected: []string{"42", "Bob  ", "ABC"},
+		},
+		{
+			name:     "right",
+			trim:     TrimRight,
+			line:     "  42 Bob  ABC",
+			expected: []string{"  42", " Bob", "ABC"},
+		},
+		{
+			name:     "none",
+			trim:     TrimNone,
+			line:     "  42 Bob  ABC",
+			expected: []string{"  42", " Bob  ", "ABC"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			actual := Layout{Fields: fields, Trim: tt.trim}.Record(tt.line)
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
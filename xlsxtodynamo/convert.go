@@ -0,0 +1,383 @@
+package xlsxtodynamo
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/a-h/ddbimport/keys"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Converter converts the rows of a sheet to DynamoDB records, using the header row as the attribute
+// names. The sheet is streamed, so large sheets aren't loaded into memory.
+type Converter struct {
+	wb  *Workbook
+	r   io.ReadCloser
+	d   *xml.Decoder
+	eof bool
+	// columns are the names of the header cells, by column index.
+	columns []string
+	names   []string
+	// types of the cells of the first row, which are used to validate the table keys.
+	types map[string]string
+	// keyTypes are the types of the columns that have been used to validate the table keys, which
+	// the cells of every row are checked against.
+	keyTypes map[string]string
+	// pending is the first row, which is read to find the types of the cells.
+	pending  map[string]*dynamodb.AttributeValue
+	line     int64
+	lastRow  int64
+	rowCount int64
+	// NullAttributes converts empty cells to NULL attributes instead of omitting them.
+	NullAttributes bool
+}
+
+// NewConverter creates a converter of the sheet with the name, or at the position starting at 1. The
+// first sheet is used if the name is empty. The header is the first row after the skipped rows.
+func (wb *Workbook) NewConverter(name string, skipRows int) (c *Converter, err error) {
+	s, err := wb.sheet(name)
+	if err != nil {
+		return
+	}
+	r, err := wb.open(s.part)
+	if err != nil {
+		return
+	}
+	c = &Converter{
+		wb:       wb,
+		r:        r,
+		d:        xml.NewDecoder(r),
+		types:    map[string]string{},
+		keyTypes: map[string]string{},
+	}
+	for {
+		var row xmlRow
+		row, err = c.next()
+		if err == io.EOF {
+			// An empty sheet has no columns or rows.
+			return c, nil
+		}
+		if err != nil {
+			return
+		}
+		if c.line <= int64(skipRows) || len(row.Cells) == 0 {
+			continue
+		}
+		if err = c.header(row); err != nil {
+			return
+		}
+		break
+	}
+	if c.lastRow > c.line {
+		c.rowCount = c.lastRow - c.line
+	}
+	c.pending, err = c.read()
+	if err == io.EOF {
+		return c, nil
+	}
+	for k, v := range c.pending {
+		c.types[k] = attributeType(v)
+	}
+	return c, err
+}
+
+func (c *Converter) header(row xmlRow) (err error) {
+	c.columns = nil
+	col := -1
+	for _, cell := range row.Cells {
+		if col, err = column(cell.R, col); err != nil {
+			return fmt.Errorf("xlsxtodynamo: row %d: %w", c.line, err)
+		}
+		for len(c.columns) <= col {
+			c.columns = append(c.columns, "")
+		}
+		av, err := c.value(cell)
+		if err != nil {
+			return fmt.Errorf("xlsxtodynamo: row %d: %w", c.line, err)
+		}
+		if av == nil {
+			// Columns without a header are ignored.
+			continue
+		}
+		c.columns[col] = text(av)
+		c.names = append(c.names, c.columns[col])
+	}
+	return nil
+}
+
+// next reads the next row of the sheet.
+func (c *Converter) next() (row xmlRow, err error) {
+	if c.eof {
+		return row, io.EOF
+	}
+	for {
+		var t xml.Token
+		t, err = c.d.Token()
+		if err == io.EOF {
+			c.eof = true
+			c.r.Close()
+			return
+		}
+		if err != nil {
+			return row, fmt.Errorf("xlsxtodynamo: row %d: %w", c.line+1, err)
+		}
+		se, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch se.Name.Local {
+		case "dimension":
+			// The range of cells in the sheet, e.g. A1:D100, is used to count the rows.
+			for _, a := range se.Attr {
+				if a.Name.Local == "ref" {
+					c.lastRow = lastRow(a.Value)
+				}
+			}
+		case "row":
+			if err = c.d.DecodeElement(&row, &se); err != nil {
+				return row, fmt.Errorf("xlsxtodynamo: row %d: %w", c.line+1, err)
+			}
+			if row.R == 0 {
+				row.R = c.line + 1
+			}
+			c.line = row.R
+			return row, nil
+		}
+	}
+}
+
+// ReadBatch reads 25 items from the sheet.
+func (c *Converter) ReadBatch() (items []map[string]*dynamodb.AttributeValue, read int, err error) {
+	batchSize := 25
+	items = make([]map[string]*dynamodb.AttributeValue, batchSize)
+	for read = 0; read < batchSize; read++ {
+		items[read], err = c.Read()
+		if err != nil {
+			break
+		}
+	}
+	return items[:read], read, err
+}
+
+// Read a single item. Numbers are converted to N attributes, booleans to BOOL attributes, and text
+// to S attributes. Numbers formatted as dates or times are converted to S attributes in ISO 8601
+// format. Empty rows are skipped, and empty cells and cells containing errors, such as #N/A, are
+// omitted. An error is returned if a cell of a key column has a different type to the table key, or
+// contains a number that DynamoDB can't store.
+func (c *Converter) Read() (item map[string]*dynamodb.AttributeValue, err error) {
+	if c.pending != nil {
+		item, c.pending = c.pending, nil
+	} else if item, err = c.read(); err != nil {
+		return nil, err
+	}
+	for column, expected := range c.keyTypes {
+		if av := item[column]; av != nil && attributeType(av) != expected {
+			return nil, fmt.Errorf("xlsxtodynamo: row %d: the %q key is a %s value, but the table key has type %s", c.line, column, attributeType(av), expected)
+		}
+	}
+	if c.NullAttributes {
+		for _, name := range c.names {
+			if item[name] == nil {
+				item[name] = nullValue
+			}
+		}
+	}
+	return item, nil
+}
+
+// read the next row that isn't empty.
+func (c *Converter) read() (item map[string]*dynamodb.AttributeValue, err error) {
+	if c.columns == nil {
+		return nil, io.EOF
+	}
+	for {
+		var row xmlRow
+		if row, err = c.next(); err != nil {
+			return nil, err
+		}
+		item = make(map[string]*dynamodb.AttributeValue, len(c.names))
+		col := -1
+		for _, cell := range row.Cells {
+			if col, err = column(cell.R, col); err != nil {
+				return nil, fmt.Errorf("xlsxtodynamo: row %d: %w", c.line, err)
+			}
+			if col >= len(c.columns) || c.columns[col] == "" {
+				continue
+			}
+			av, err := c.value(cell)
+			if err != nil {
+				return nil, fmt.Errorf("xlsxtodynamo: row %d: %s: %w", c.line, c.columns[col], err)
+			}
+			if av != nil {
+				item[c.columns[col]] = av
+			}
+		}
+		if len(item) > 0 {
+			return item, nil
+		}
+	}
+}
+
+// Line returns the number of the last row read within the sheet, starting at 1.
+func (c *Converter) Line() int64 {
+	return c.line
+}
+
+// Rows returns the number of rows after the header, according to the dimensions of the sheet.
+func (c *Converter) Rows() int64 {
+	return c.rowCount
+}
+
+// Columns returns the names of the header cells.
+func (c *Converter) Columns() []string {
+	return c.names
+}
+
+// AttributeType returns the DynamoDB type of the column in the first row, which is used to validate
+// the table keys. Other rows may contain values of other types, so the cells of the column are
+// checked against the type in every row that's read, and Read returns an error if they differ.
+func (c *Converter) AttributeType(column string) string {
+	t, ok := c.types[column]
+	if !ok {
+		t = dynamodb.ScalarAttributeTypeS
+	}
+	c.keyTypes[column] = t
+	return t
+}
+
+func (c *Converter) value(cell xmlCell) (*dynamodb.AttributeValue, error) {
+	switch cell.T {
+	case "s":
+		i, err := strconv.Atoi(cell.V)
+		if err != nil || i < 0 || i >= len(c.wb.shared) {
+			return nil, nil
+		}
+		return str(c.wb.shared[i]), nil
+	case "inlineStr":
+		return str(cell.IS.String()), nil
+	case "str", "d":
+		// Formulas that return text, and dates in ISO 8601 format.
+		return str(cell.V), nil
+	case "b":
+		if cell.V == "" {
+			return nil, nil
+		}
+		return &dynamodb.AttributeValue{BOOL: aws.Bool(cell.V == "1" || cell.V == "true")}, nil
+	case "e":
+		return nil, nil
+	}
+	if cell.V == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(cell.V, 64)
+	if err != nil {
+		return str(cell.V), nil
+	}
+	if cell.S >= 0 && cell.S < len(c.wb.dateStyles) && c.wb.dateStyles[cell.S] {
+		return str(formatTime(f, excelTime(f, c.wb.date1904))), nil
+	}
+	if err = keys.ValidNumber(cell.V); err != nil {
+		return nil, err
+	}
+	if isDecimal(cell.V) {
+		return &dynamodb.AttributeValue{N: aws.String(cell.V)}, nil
+	}
+	// Numbers in scientific notation, e.g. 1E-3.
+	return &dynamodb.AttributeValue{N: aws.String(strconv.FormatFloat(f, 'f', -1, 64))}, nil
+}
+
+var nullValue = &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+
+func str(s string) *dynamodb.AttributeValue {
+	if s == "" {
+		return nil
+	}
+	return &dynamodb.AttributeValue{S: aws.String(s)}
+}
+
+func text(av *dynamodb.AttributeValue) string {
+	switch {
+	case av.S != nil:
+		return *av.S
+	case av.N != nil:
+		return *av.N
+	case av.BOOL != nil:
+		return strings.ToUpper(strconv.FormatBool(*av.BOOL))
+	}
+	return ""
+}
+
+func attributeType(av *dynamodb.AttributeValue) string {
+	switch {
+	case av.N != nil:
+		return dynamodb.ScalarAttributeTypeN
+	case av.BOOL != nil:
+		return "BOOL"
+	}
+	return dynamodb.ScalarAttributeTypeS
+}
+
+// isDecimal returns true if s is an optionally signed decimal number, without an exponent.
+func isDecimal(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	var digits, points int
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '.':
+			points++
+		default:
+			return false
+		}
+	}
+	return digits > 0 && points <= 1
+}
+
+// column returns the index of the column of a cell reference, e.g. 0 for A1 and 27 for AB3. Cells
+// without a reference follow the previous cell.
+func column(ref string, previous int) (int, error) {
+	if ref == "" {
+		return previous + 1, nil
+	}
+	col := 0
+	var i int
+	for ; i < len(ref); i++ {
+		c := ref[i]
+		if c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		col = col*26 + int(c-'A'+1)
+	}
+	if i == 0 {
+		return 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return col - 1, nil
+}
+
+// lastRow returns the row of the last cell of a range, e.g. 100 for A1:D100.
+func lastRow(ref string) int64 {
+	ref = ref[strings.LastIndex(ref, ":")+1:]
+	row, _ := strconv.ParseInt(strings.TrimLeft(ref, "ABCDEFGHIJKLMNOPQRSTUVWXYZ$"), 10, 64)
+	return row
+}
+
+type xmlRow struct {
+	R     int64     `xml:"r,attr"`
+	Cells []xmlCell `xml:"c"`
+}
+
+type xmlCell struct {
+	R  string  `xml:"r,attr"`
+	T  string  `xml:"t,attr"`
+	S  int     `xml:"s,attr"`
+	V  string  `xml:"v"`
+	IS xmlText `xml:"is"`
+}
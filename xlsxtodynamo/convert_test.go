@@ -0,0 +1,284 @@
+package xlsxtodynamo
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/google/go-cmp/cmp"
+)
+
+const relationships = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const workbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Notes" sheetId="1" r:id="rId1"/><sheet name="Prices" sheetId="2" r:id="rId2"/></sheets>
+</workbook>`
+
+const workbookRelationships = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="/xl/worksheets/sheet2.xml"/>
+<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings" Target="sharedStrings.xml"/>
+<Relationship Id="rId4" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+const sharedStrings = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>sku</t></si><si><t>price</t></si><si><t>inStock</t></si><si><t>launched</t></si><si><t>updated</t></si>
+<si><r><t>Blue </t></r><r><t>widget</t></r></si>
+</sst>`
+
+// Style 1 is a built-in date format, and style 2 is a custom date and time format.
+const styles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy\-mm\-dd\ hh:mm"/></numFmts>
+<cellXfs count="3"><xf numFmtId="0"/><xf numFmtId="14"/><xf numFmtId="164"/></cellXfs>
+</styleSheet>`
+
+const notes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData/></worksheet>`
+
+const prices = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<dimension ref="A1:F6"/>
+<sheetData>
+<row r="1"><c r="A1" t="inlineStr"><is><t>Price list</t></is></c></row>
+<row r="2"><c r="A2" t="s"><v>0</v></c><c r="B2" t="s"><v>1</v></c><c r="C2" t="s"><v>2</v></c><c r="D2" t="s"><v>3</v></c><c r="E2" t="s"><v>4</v></c></row>
+<row r="3"><c r="A3" t="s"><v>5</v></c><c r="B3"><v>12.5</v></c><c r="C3" t="b"><v>1</v></c><c r="D3" s="1"><v>43831</v></c><c r="E3" s="2"><v>43831.5</v></c><c r="F3"><v>99</v></c></row>
+<row r="4"><c r="A4" t="str"><f>A3</f><v>copy</v></c><c r="B4"><v>1.5E-3</v></c><c r="C4" t="e"><v>#N/A</v></c></row>
+<row r="5"></row>
+<row r="6"><c r="A6" t="inlineStr"><is><t>last</t></is></c><c r="C6" t="b"><v>0</v></c></row>
+</sheetData>
+</worksheet>`
+
+func workbookFile(t *testing.T) *bytes.Reader {
+	return workbookFileWithNotes(t, notes)
+}
+
+// workbookFileWithNotes creates a workbook where the first sheet is replaced by the given sheet.
+func workbookFileWithNotes(t *testing.T, notes string) *bytes.Reader {
+	var buf bytes.Buffer
+	z := zip.NewWriter(&buf)
+	parts := map[string]string{
+		"_rels/.rels":                relationships,
+		"xl/workbook.xml":            workbook,
+		"xl/_rels/workbook.xml.rels": workbookRelationships,
+		"xl/sharedStrings.xml":       sharedStrings,
+		"xl/styles.xml":              styles,
+		"xl/worksheets/sheet1.xml":   notes,
+		"xl/worksheets/sheet2.xml":   prices,
+	}
+	for name, content := range parts {
+		w, err := z.Create(name)
+		if err != nil {
+			t.Fatalf("failed to create part: %v", err)
+		}
+		if _, err = io.WriteString(w, content); err != nil {
+			t.Fatalf("failed to write part: %v", err)
+		}
+	}
+	if err := z.Close(); err != nil {
+		t.Fatalf("failed to close zip: %v", err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func readAll(t *testing.T, c *Converter) (items []map[string]*dynamodb.AttributeValue) {
+	for {
+		item, err := c.Read()
+		if err == io.EOF {
+			return items
+		}
+		if err != nil {
+			t.Fatalf("failed to read: %v", err)
+		}
+		items = append(items, item)
+	}
+}
+
+func TestConverter(t *testing.T) {
+	r := workbookFile(t)
+	wb, err := Open(r, r.Size())
+	if err != nil {
+		t.Fatalf("failed to open: %v", err)
+	}
+	if diff := cmp.Diff([]string{"Notes", "Prices"}, wb.Sheets()); diff != "" {
+		t.Error(diff)
+	}
+	for _, name := range []string{"Prices", "2"} {
+		c, err := wb.NewConverter(name, 1)
+		if err != nil {
+			t.Fatalf("failed to create converter for sheet %q: %v", name, err)
+		}
+		if diff := cmp.Diff([]string{"sku", "price", "inStock", "launched", "updated"}, c.Columns()); diff != "" {
+			t.Error(diff)
+		}
+		if c.AttributeType("price") != "N" || c.AttributeType("inStock") != "BOOL" || c.AttributeType("sku") != "S" {
+			t.Errorf("unexpected types of the first row: %v", c.types)
+		}
+		if c.Rows() != 4 {
+			t.Errorf("expected 4 rows, got %d", c.Rows())
+		}
+		expected := []map[string]*dynamodb.AttributeValue{
+			{
+				"sku":      {S: aws.String("Blue widget")},
+				"price":    {N: aws.String("12.5")},
+				"inStock":  {BOOL: aws.Bool(true)},
+				"launched": {S: aws.String("2020-01-01")},
+				"updated":  {S: aws.String("2020-01-01T12:00:00")},
+			},
+			{
+				"sku":   {S: aws.String("copy")},
+				"price": {N: aws.String("0.0015")},
+			},
+			{
+				"sku":     {S: aws.String("last")},
+				"inStock": {BOOL: aws.Bool(false)},
+			},
+		}
+		if diff := cmp.Diff(expected, readAll(t, c)); diff != "" {
+			t.Error(diff)
+		}
+		if c.Line() != 6 {
+			t.Errorf("expected line 6, got %d", c.Line())
+		}
+	}
+}
+
+func TestConverterNullAttributes(t *testing.T) {
+	r := workbookFile(t)
+	wb, err := Open(r, r.Size())
+	if err != nil {
+		t.Fatalf("failed to open: %v", err)
+	}
+	c, err := wb.NewConverter("Prices", 1)
+	if err != nil {
+		t.Fatalf("failed to create converter: %v", err)
+	}
+	c.NullAttributes = true
+	items := readAll(t, c)
+	if len(items) != 3 {
+		t.Fatalf("expected 3 items, got %d", len(items))
+	}
+	if diff := cmp.Diff(nullValue, items[2]["price"]); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestConverterSheets(t *testing.T) {
+	r := workbookFile(t)
+	wb, err := Open(r, r.Size())
+	if err != nil {
+		t.Fatalf("failed to open: %v", err)
+	}
+	c, err := wb.NewConverter("", 0)
+	if err != nil {
+		t.Fatalf("failed to create converter for the first sheet: %v", err)
+	}
+	if items := readAll(t, c); len(items) != 0 {
+		t.Errorf("expected the empty first sheet to have no items, got %v", items)
+	}
+	for _, name := range []string{"Missing", "0", "3"} {
+		if _, err = wb.NewConverter(name, 0); err == nil {
+			t.Errorf("expected an error for sheet %q", name)
+		}
+	}
+}
+
+func TestConverterErrors(t *testing.T) {
+	var tests = []struct {
+		name     string
+		rows     string
+		key      string
+		expected string
+	}{
+		{
+			name:     "key cells must have the type of the first row",
+			rows:     `<row r="2"><c r="A2"><v>1</v></c></row><row r="3"><c r="A3" t="inlineStr"><is><t>two</t></is></c></row>`,
+			key:      "id",
+			expected: `xlsxtodynamo: row 3: the "id" key is a S value, but the table key has type N`,
+		},
+		{
+			name:     "numbers that DynamoDB can't store are rejected",
+			rows:     `<row r="2"><c r="A2"><v>1</v></c></row><row r="3"><c r="A3"><v>1E+300</v></c></row>`,
+			expected: `xlsxtodynamo: row 3: id: keys: "1E+300" is outside the range of DynamoDB numbers, 1E-130 to 1E+126`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			sheet := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="inlineStr"><is><t>id</t></is></c></row>` + tt.rows + `</sheetData></worksheet>`
+			r := workbookFileWithNotes(t, sheet)
+			wb, err := Open(r, r.Size())
+			if err != nil {
+				t.Fatalf("failed to open: %v", err)
+			}
+			c, err := wb.NewConverter("Notes", 0)
+			if err != nil {
+				t.Fatalf("failed to create converter: %v", err)
+			}
+			if tt.key != "" {
+				c.AttributeType(tt.key)
+			}
+			if _, err = c.Read(); err != nil {
+				t.Fatalf("unexpected error reading the first row: %v", err)
+			}
+			_, err = c.Read()
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("expected error %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestIsDateFormatCode(t *testing.T) {
+	var tests = []struct {
+		code     string
+		expected bool
+	}{
+		{code: "General", expected: false},
+		{code: "0.00", expected: false},
+		{code: "#,##0 \"days\"", expected: false},
+		{code: "[Magenta]0.00", expected: false},
+		{code: "0.00E+00", expected: false},
+		{code: "dd/mm/yyyy", expected: true},
+		{code: "[$-409]mmm d, yyyy", expected: true},
+		{code: "[h]:mm", expected: true},
+		{code: "h:mm AM/PM", expected: true},
+	}
+	for _, tt := range tests {
+		if actual := isDateFormatCode(tt.code); actual != tt.expected {
+			t.Errorf("%q: expected %v, got %v", tt.code, tt.expected, actual)
+		}
+	}
+}
+
+func TestExcelTime(t *testing.T) {
+	var tests = []struct {
+		serial   float64
+		date1904 bool
+		expected string
+	}{
+		{serial: 1, expected: "1900-01-01"},
+		{serial: 59, expected: "1900-02-28"},
+		{serial: 61, expected: "1900-03-01"},
+		{serial: 43831, expected: "2020-01-01"},
+		{serial: 43831.25, expected: "2020-01-01T06:00:00"},
+		{serial: 0.5, expected: "12:00:00"},
+		{serial: 42369, date1904: true, expected: "2020-01-01"},
+	}
+	for _, tt := range tests {
+		if actual := formatTime(tt.serial, excelTime(tt.serial, tt.date1904)); actual != tt.expected {
+			t.Errorf("%v (1904 %v): expected %q, got %q", tt.serial, tt.date1904, tt.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package xlsxtodynamo
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Workbook is an XLSX file, which is a zip archive of XML parts, so it's read using random access.
+type Workbook struct {
+	z      *zip.Reader
+	sheets []sheet
+	// shared strings referenced by the cells of the sheets.
+	shared []string
+	// dateStyles are the cell styles that format numbers as dates or times.
+	dateStyles []bool
+	date1904   bool
+}
+
+type sheet struct {
+	name string
+	part string
+}
+
+// Open reads the sheets, shared strings and styles of an XLSX file.
+func Open(r io.ReaderAt, size int64) (wb *Workbook, err error) {
+	wb = &Workbook{}
+	if wb.z, err = zip.NewReader(r, size); err != nil {
+		return nil, fmt.Errorf("xlsxtodynamo: not an XLSX file: %w", err)
+	}
+	// The package relationships locate the workbook, and the workbook relationships locate its parts.
+	var rels xmlRelationships
+	if err = wb.decode("_rels/.rels", &rels); err != nil {
+		return
+	}
+	workbookPart := rels.target("", "/officeDocument")
+	if workbookPart == "" {
+		return nil, fmt.Errorf("xlsxtodynamo: the file doesn't contain a workbook")
+	}
+	var workbook xmlWorkbook
+	if err = wb.decode(workbookPart, &workbook); err != nil {
+		return
+	}
+	wb.date1904 = workbook.Properties.Date1904 == "1" || workbook.Properties.Date1904 == "true"
+	dir := path.Dir(workbookPart)
+	var workbookRels xmlRelationships
+	if err = wb.decode(path.Join(dir, "_rels", path.Base(workbookPart)+".rels"), &workbookRels); err != nil {
+		return
+	}
+	for _, s := range workbook.Sheets {
+		wb.sheets = append(wb.sheets, sheet{name: s.Name, part: workbookRels.targetByID(dir, s.ID)})
+	}
+	if part := workbookRels.target(dir, "/sharedStrings"); part != "" {
+		var sst xmlSharedStrings
+		if err = wb.decode(part, &sst); err != nil {
+			return
+		}
+		wb.shared = make([]string, len(sst.Items))
+		for i, si := range sst.Items {
+			wb.shared[i] = si.String()
+		}
+	}
+	if part := workbookRels.target(dir, "/styles"); part != "" {
+		var styles xmlStyles
+		if err = wb.decode(part, &styles); err != nil {
+			return
+		}
+		formats := map[int]string{}
+		for _, f := range styles.NumFmts {
+			formats[f.ID] = f.Code
+		}
+		wb.dateStyles = make([]bool, len(styles.CellXfs))
+		for i, xf := range styles.CellXfs {
+			code, custom := formats[xf.NumFmtID]
+			wb.dateStyles[i] = isDateFormat(xf.NumFmtID) || custom && isDateFormatCode(code)
+		}
+	}
+	return wb, nil
+}
+
+// Sheets returns the names of the sheets in the workbook.
+func (wb *Workbook) Sheets() []string {
+	names := make([]string, len(wb.sheets))
+	for i, s := range wb.sheets {
+		names[i] = s.name
+	}
+	return names
+}
+
+// sheet finds a sheet by name, or by its position in the workbook, starting at 1. The first sheet is
+// used if the name is empty.
+func (wb *Workbook) sheet(name string) (s sheet, err error) {
+	if len(wb.sheets) == 0 {
+		return s, fmt.Errorf("xlsxtodynamo: the workbook doesn't contain any sheets")
+	}
+	if name == "" {
+		return wb.sheets[0], nil
+	}
+	for _, s := range wb.sheets {
+		if s.name == name {
+			return s, nil
+		}
+	}
+	if i, err := strconv.Atoi(name); err == nil && i >= 1 && i <= len(wb.sheets) {
+		return wb.sheets[i-1], nil
+	}
+	return s, fmt.Errorf("xlsxtodynamo: sheet %q not found, expected one of %q or a position from 1 to %d", name, wb.Sheets(), len(wb.sheets))
+}
+
+func (wb *Workbook) open(name string) (io.ReadCloser, error) {
+	for _, f := range wb.z.File {
+		if f.Name == name {
+			return f.Open()
+		}
+	}
+	return nil, fmt.Errorf("xlsxtodynamo: part %q not found", name)
+}
+
+func (wb *Workbook) decode(name string, v interface{}) (err error) {
+	r, err := wb.open(name)
+	if err != nil {
+		return
+	}
+	defer r.Close()
+	if err = xml.NewDecoder(r).Decode(v); err != nil {
+		return fmt.Errorf("xlsxtodynamo: failed to read %q: %w", name, err)
+	}
+	return
+}
+
+// isDateFormat returns true if the built-in number format displays a date or time.
+func isDateFormat(id int) bool {
+	return id >= 14 && id <= 22 || id >= 27 && id <= 36 || id >= 45 && id <= 47 || id >= 50 && id <= 58
+}
+
+// isDateFormatCode returns true if the custom number format contains date or time placeholders,
+// ignoring literal text, escaped characters and colours.
+func isDateFormatCode(code string) bool {
+	var quoted, escaped bool
+	var bracket *strings.Builder
+	for _, c := range strings.ToLower(code) {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted:
+			quoted = c != '"'
+		case bracket != nil && c == ']':
+			// Elapsed times, e.g. [h]:mm, are bracketed like colours and locales.
+			if b := bracket.String(); b != "" && strings.Trim(b, "hms") == "" {
+				return true
+			}
+			bracket = nil
+		case bracket != nil:
+			bracket.WriteRune(c)
+		case c == '"':
+			quoted = true
+		case c == '[':
+			bracket = &strings.Builder{}
+		case c == '\\' || c == '_' || c == '*':
+			escaped = true
+		case c == 'y' || c == 'm' || c == 'd' || c == 'h' || c == 's':
+			return true
+		}
+	}
+	return false
+}
+
+// excelTime converts a serial date, the number of days since the epoch of the workbook, to a time.
+func excelTime(serial float64, date1904 bool) time.Time {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	} else if serial >= 1 && serial < 60 {
+		// The 1900 date system includes 29 February 1900, which didn't exist.
+		serial++
+	}
+	days := math.Floor(serial)
+	ms := math.Round((serial - days) * 24 * 60 * 60 * 1000)
+	return epoch.AddDate(0, 0, int(days)).Add(time.Duration(ms) * time.Millisecond)
+}
+
+// formatTime formats times of day, dates and date times in ISO 8601 format.
+func formatTime(serial float64, t time.Time) string {
+	if serial < 1 {
+		return t.Format("15:04:05.999")
+	}
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02T15:04:05.999")
+}
+
+type xmlRelationships struct {
+	Items []struct {
+		ID     string `xml:"Id,attr"`
+		Type   string `xml:"Type,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// target returns the part of the first relationship with the type suffix.
+func (rels xmlRelationships) target(dir, typeSuffix string) string {
+	for _, r := range rels.Items {
+		if strings.HasSuffix(r.Type, typeSuffix) {
+			return resolve(dir, r.Target)
+		}
+	}
+	return ""
+}
+
+func (rels xmlRelationships) targetByID(dir, id string) string {
+	for _, r := range rels.Items {
+		if r.ID == id {
+			return resolve(dir, r.Target)
+		}
+	}
+	return ""
+}
+
+// resolve a relationship target, which is either relative to the directory of the source part, or
+// absolute within the package.
+func resolve(dir, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(target, "/")
+	}
+	return path.Join(dir, target)
+}
+
+type xmlWorkbook struct {
+	Properties struct {
+		Date1904 string `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xmlSharedStrings struct {
+	Items []xmlText `xml:"si"`
+}
+
+// xmlText is plain text, or rich text made of runs. Phonetic runs are ignored.
+type xmlText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xmlText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var sb strings.Builder
+	sb.WriteString(t.T)
+	for _, r := range t.Runs {
+		sb.WriteString(r.T)
+	}
+	return sb.String()
+}
+
+type xmlStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}